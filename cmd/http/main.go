@@ -7,52 +7,115 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/thaian1234/green_light/config"
 	"github.com/thaian1234/green_light/internal/adapter/http"
+	"github.com/thaian1234/green_light/internal/adapter/ratelimit"
 	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+	"github.com/thaian1234/green_light/internal/adapter/storages/redis"
 	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/tracing"
 )
 
+const configWatchInterval = 5 * time.Second
+
 func main() {
 	ctx := context.Background()
 	var wg sync.WaitGroup
 
-	cfg, err := config.Load()
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
+	if cfg.PrintConfig {
+		if err := cfg.WriteMasked(os.Stdout); err != nil {
+			log.Fatalf("failed to print config: %v", err)
+		}
+		return
+	}
+	if len(cfg.Args) > 0 && cfg.Args[0] == "migrate" {
+		if err := runMigrate(cfg, cfg.Args[1:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
 
 	err = logger.Initialize(cfg.Logger)
 	if err != nil {
 		log.Fatalf("failed to load logger: %v", err)
 	}
 
+	shutdownTracing, err := tracing.Initialize(ctx, cfg.Tracing, cfg.App)
+	if err != nil {
+		log.Fatalf("failed to setup tracing: %v", err)
+	}
+
 	dbAdapter, err := postgres.NewAdapter(ctx, cfg.DB)
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
 	defer dbAdapter.Close()
 
+	if cfg.DB.RequireLatestSchema {
+		if err := checkSchema(cfg.DB); err != nil {
+			log.Fatalf("refusing to start: %v", err)
+		}
+	}
+
+	// Redis is only needed when the rate limiter is shared between replicas
+	var redisAdapter *redis.Adapter
+	if cfg.Limiter.Store == ratelimit.StoreRedis {
+		redisAdapter, err = redis.NewAdapter(ctx, cfg.Redis)
+		if err != nil {
+			log.Fatalf("failed to connect to redis: %v", err)
+		}
+		defer redisAdapter.Close()
+	}
+
+	// Limiter, CORS and log settings are reloaded from the config file and on SIGHUP
+	live := config.NewLive(cfg)
+	// Only a change to the configured levels resets the ones set through the admin
+	// endpoint.
+	live.OnChange(func(cfg *config.Config, changes []config.Change) {
+		if !config.Applied(changes, "logger.level", "logger.levels") {
+			return
+		}
+		if err := logger.ReloadLevels(cfg.Logger); err != nil {
+			logger.Error("failed to reload log levels", "err", err)
+		}
+	})
+	live.Watch(configWatchInterval, logReload)
+
 	// Pass wg to your adapters/services that need it
-	httpAdapter := http.NewAdapter(cfg, dbAdapter, &wg)
+	httpAdapter := http.NewAdapter(live, dbAdapter, redisAdapter, &wg)
 	errChan := make(chan error)
 
 	go func() {
 		errChan <- httpAdapter.Run()
 	}()
 
-	// Wait for server error or interrupt signal
+	// Wait for server error or interrupt signal; SIGHUP reloads the config
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	select {
-	case err := <-errChan:
-		log.Printf("Server error: %v", err)
-	case sig := <-sigChan:
-		log.Printf("Received signal: %v", sig)
-	case <-ctx.Done():
-		log.Println("Context cancelled")
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+wait:
+	for {
+		select {
+		case err := <-errChan:
+			log.Printf("Server error: %v", err)
+			break wait
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				logReload(live.Reload())
+				continue
+			}
+			log.Printf("Received signal: %v", sig)
+			break wait
+		case <-ctx.Done():
+			log.Println("Context cancelled")
+			break wait
+		}
 	}
 
 	log.Println("Shutting down server...")
@@ -61,5 +124,24 @@ func main() {
 	// Wait for all background tasks to complete
 	log.Println("Waiting for background tasks to complete...")
 	wg.Wait()
+
+	// Flush spans of requests and background tasks that just finished
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("failed to flush traces: %v", err)
+	}
 	log.Println("Server shutdown completed")
 }
+
+func logReload(changes []config.Change, err error) {
+	if err != nil {
+		logger.Error("rejected config reload, keeping the running config", "err", err)
+		return
+	}
+	for _, change := range changes {
+		if change.Applied {
+			logger.Info("config reloaded", "change", change.String())
+			continue
+		}
+		logger.Warn("config change needs a restart", "change", change.String())
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+)
+
+const migrateUsage = "usage: migrate up | down N | goto V | version | force V"
+
+// runMigrate implements the migrate subcommand against the embedded migrations.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	migrator, err := postgres.NewMigrator(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		var steps int
+		if steps, err = intArg(args); err == nil {
+			err = migrator.Down(steps)
+		}
+	case "goto":
+		var version int
+		if version, err = intArg(args); err == nil {
+			if version < 0 {
+				return errors.New(migrateUsage)
+			}
+			err = migrator.Goto(uint(version))
+		}
+	case "force":
+		var version int
+		if version, err = intArg(args); err == nil {
+			err = migrator.Force(version)
+		}
+	case "version":
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	latest, err := migrator.Latest()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version %d of %d (dirty: %t)\n", version, latest, dirty)
+	return nil
+}
+
+func intArg(args []string) (int, error) {
+	if len(args) != 2 {
+		return 0, errors.New(migrateUsage)
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, errors.New(migrateUsage)
+	}
+	return n, nil
+}
+
+// checkSchema fails when the database has not been migrated to the embedded version.
+func checkSchema(cfg *config.DB) error {
+	migrator, err := postgres.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	return migrator.CheckSchema()
+}
@@ -1,160 +1,184 @@
 package config
 
 import (
-	"os"
-	"strconv"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
-// Config contains environment variables for the application, database, cache, token, logger and http server
+// Config contains the settings for the application, database, cache, token, logger and
+// http server. Every field is read, in increasing order of precedence, from its
+// default, the config file given by --config (YAML or TOML), its environment variable
+// and its command-line flag, named after the field's path, e.g. --limiter.rps.
 type (
 	Config struct {
-		App     *App
-		Token   *Token
-		Redis   *Redis
-		DB      *DB
-		HTTP    *HTTP
-		Logger  *Logger
-		Limiter *Limiter
-		Smtp    *SMTP
+		App     *App     `yaml:"app"`
+		Token   *Token   `yaml:"token"`
+		Redis   *Redis   `yaml:"redis"`
+		DB      *DB      `yaml:"db"`
+		HTTP    *HTTP    `yaml:"http"`
+		Logger  *Logger  `yaml:"logger"`
+		Limiter *Limiter `yaml:"limiter"`
+		Smtp    *SMTP    `yaml:"smtp"`
+		Login   *Login   `yaml:"login"`
+		OIDC    *OIDC    `yaml:"oidc"`
+		Access  *Access  `yaml:"access"`
+		Tracing *Tracing `yaml:"tracing"`
+
+		// PrintConfig is set by --print-config; Args holds the arguments after the
+		// flags, such as a subcommand.
+		PrintConfig bool     `yaml:"-"`
+		Args        []string `yaml:"-"`
 	}
 	// App contains all the environment variables for the application
 	App struct {
-		Name    string
-		Env     string
-		Version string
+		Name    string `yaml:"name" env:"APP_NAME" default:"green_light"`
+		Env     string `yaml:"env" env:"APP_ENV" default:"development"`
+		Version string `yaml:"version" env:"APP_VERSION"`
 	}
 	// Token contains all the environment variables for the token service
 	Token struct {
-		Duration string
+		Duration time.Duration `yaml:"duration" env:"TOKEN_DURATION" default:"24h"`
 	}
 	// Redis contains all the environment variables for the cache service
 	Redis struct {
-		Addr     string
-		Password string
-	}
-	// Database contains all the environment variables for the database
+		Addr     string `yaml:"addr" env:"REDIS_ADDR" default:"localhost:6379"`
+		Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	}
+	// Database contains all the environment variables for the database. When
+	// RequireLatestSchema is set the server refuses to start while migrations are
+	// pending. StatementTimeout is enforced by the server for every statement, while
+	// QueryTimeout bounds each repository query from the client side; zero disables
+	// either. Replicas are connection URLs of read replicas, which are taken out of
+	// rotation while unreachable or lagging more than ReplicaMaxLag behind the primary.
 	DB struct {
-		Connection string
-		Host       string
-		Port       string
-		User       string
-		Password   string
-		Name       string
+		Connection  string `yaml:"connection" env:"DB_CONNECTION" default:"postgres"`
+		Host        string `yaml:"host" env:"DB_HOST" default:"localhost"`
+		Port        int    `yaml:"port" env:"DB_PORT" default:"5432"`
+		User        string `yaml:"user" env:"DB_USER"`
+		Password    string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+		Name        string `yaml:"name" env:"DB_NAME"`
+		SSLMode     string `yaml:"sslmode" env:"DB_SSLMODE" default:"disable"`
+		SSLRootCert string `yaml:"sslrootcert" env:"DB_SSLROOTCERT"`
+
+		MaxConns          int           `yaml:"max_conns" env:"DB_MAX_CONNS" default:"25"`
+		MinConns          int           `yaml:"min_conns" env:"DB_MIN_CONNS" default:"0"`
+		MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME" default:"1h"`
+		MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME" default:"30m"`
+		HealthCheckPeriod time.Duration `yaml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD" default:"1m"`
+		StatementTimeout  time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" default:"30s"`
+		QueryTimeout      time.Duration `yaml:"query_timeout" env:"DB_QUERY_TIMEOUT" default:"5s"`
+
+		Replicas             []string      `yaml:"replicas" env:"DB_REPLICAS" secret:"true"`
+		ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" env:"DB_REPLICA_MAX_LAG" default:"10s"`
+		ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" default:"5s"`
+
+		RequireLatestSchema bool `yaml:"require_latest_schema" env:"DB_REQUIRE_LATEST_SCHEMA"`
 	}
-	// HTTP contains all the environment variables for the http server
+	// HTTP contains all the environment variables for the http server. TrustedProxies
+	// lists the CIDRs whose forwarding headers are believed; ForwardedHeader names the
+	// one they write the client address to, "x-forwarded-for" or "forwarded".
 	HTTP struct {
-		Env            string
-		URL            string
-		Port           string
-		AllowedOrigins string
-	}
-	// Logger configuration
+		URL             string   `yaml:"url" env:"HTTP_URL"`
+		Port            int      `yaml:"port" env:"HTTP_PORT" default:"8080"`
+		AllowedOrigins  []string `yaml:"allowed_origins" env:"HTTP_ALLOWED_ORIGINS"`
+		TrustedProxies  []string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES"`
+		ForwardedHeader string   `yaml:"forwarded_header" env:"HTTP_FORWARDED_HEADER" default:"x-forwarded-for"`
+	}
+	// Logger configuration. LogLevels overrides the level of sub-loggers, e.g.
+	// "http=debug,db=warn". Outputs lists "stdout" and "file"; StdoutFormat is
+	// "console" or "json" and defaults to json in production. Sampling keeps the first
+	// SamplingInitial entries with the same message each second and then every
+	// SamplingThereafter-th, and is off when SamplingInitial is zero. RedactFields adds
+	// to the keys that are always masked.
 	Logger struct {
-		LogPath     string
-		LogLevel    string
-		LogMaxSize  int
-		LogBackUps  int
-		LogMaxAge   int
-		LogCompress bool
-	}
-	// Limiter configuration
+		Env                string   `yaml:"-"`
+		LogPath            string   `yaml:"path" env:"LOG_PATH" default:"logs/app.log"`
+		LogLevel           string   `yaml:"level" env:"LOG_LEVEL" default:"info"`
+		LogLevels          string   `yaml:"levels" env:"LOG_LEVELS"`
+		LogMaxSize         int      `yaml:"max_size" env:"LOG_MAX_SIZE" default:"100"`
+		LogBackUps         int      `yaml:"backups" env:"LOG_BACKUPS" default:"3"`
+		LogMaxAge          int      `yaml:"max_age" env:"LOG_MAX_AGE" default:"28"`
+		LogCompress        bool     `yaml:"compress" env:"LOG_COMPRESS"`
+		Outputs            []string `yaml:"outputs" env:"LOG_OUTPUTS" default:"stdout,file"`
+		StdoutFormat       string   `yaml:"stdout_format" env:"LOG_STDOUT_FORMAT"`
+		SamplingInitial    int      `yaml:"sampling_initial" env:"LOG_SAMPLING_INITIAL"`
+		SamplingThereafter int      `yaml:"sampling_thereafter" env:"LOG_SAMPLING_THEREAFTER"`
+		RedactFields       []string `yaml:"redact_fields" env:"LOG_REDACT_FIELDS"`
+	}
+	// Limiter configuration. AuthRps and AuthBurst apply to registration and login;
+	// IPRps and IPBurst cap each IP address before its credentials are checked.
+	// Store selects "memory" or "redis"; FailOpen lets requests through when the store
+	// is unavailable instead of rejecting them.
 	Limiter struct {
-		Rps     int
-		Burst   int
-		Enabled bool
+		Rps       int    `yaml:"rps" env:"LIMITER_RPS" default:"2"`
+		Burst     int    `yaml:"burst" env:"LIMITER_BURST" default:"4"`
+		AuthRps   int    `yaml:"auth_rps" env:"LIMITER_AUTH_RPS" default:"1"`
+		AuthBurst int    `yaml:"auth_burst" env:"LIMITER_AUTH_BURST" default:"5"`
+		IPRps     int    `yaml:"ip_rps" env:"LIMITER_IP_RPS" default:"20"`
+		IPBurst   int    `yaml:"ip_burst" env:"LIMITER_IP_BURST" default:"40"`
+		Enabled   bool   `yaml:"enabled" env:"LIMITER_ENABLED" default:"true"`
+		Store     string `yaml:"store" env:"LIMITER_STORE" default:"memory"`
+		FailOpen  bool   `yaml:"fail_open" env:"LIMITER_FAIL_OPEN"`
 	}
 	// Mailer configuration
 	SMTP struct {
-		Host     string
-		Port     int
-		Username string
-		Password string
-		Sender   string
+		Host     string `yaml:"host" env:"SMTP_HOST"`
+		Port     int    `yaml:"port" env:"SMTP_PORT" default:"25"`
+		Username string `yaml:"username" env:"SMTP_USERNAME"`
+		Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+		Sender   string `yaml:"sender" env:"SMTP_SENDER"`
+	}
+	// Login contains the brute-force protection settings for the login endpoint
+	Login struct {
+		MaxAccountAttempts int           `yaml:"max_account_attempts" env:"LOGIN_MAX_ACCOUNT_ATTEMPTS" default:"5"`
+		MaxIPAttempts      int           `yaml:"max_ip_attempts" env:"LOGIN_MAX_IP_ATTEMPTS" default:"20"`
+		Window             time.Duration `yaml:"window" env:"LOGIN_WINDOW" default:"15m"`
+		LockoutDuration    time.Duration `yaml:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION" default:"15m"`
+		BaseDelay          time.Duration `yaml:"base_delay" env:"LOGIN_BASE_DELAY" default:"1s"`
+		MaxDelay           time.Duration `yaml:"max_delay" env:"LOGIN_MAX_DELAY" default:"30s"`
+	}
+	// OIDC contains the settings for signing in through an external OpenID Connect provider
+	OIDC struct {
+		Enabled      bool     `yaml:"enabled" env:"OIDC_ENABLED"`
+		Issuer       string   `yaml:"issuer" env:"OIDC_ISSUER"`
+		ClientID     string   `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+		ClientSecret string   `yaml:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true"`
+		RedirectURL  string   `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+		Scopes       []string `yaml:"scopes" env:"OIDC_SCOPES"`
+	}
+	// Access points at the JSON file of per route group CIDR allow and deny rules and
+	// how often it is checked for changes
+	Access struct {
+		RulesFile      string        `yaml:"rules_file" env:"ACCESS_RULES_FILE"`
+		ReloadInterval time.Duration `yaml:"reload_interval" env:"ACCESS_RELOAD_INTERVAL" default:"30s"`
+	}
+	// Tracing configures OpenTelemetry export. Exporter is "otlp" (OTLP over HTTP to
+	// Endpoint) or "stdout", which writes to FilePath when it is set.
+	Tracing struct {
+		Enabled     bool    `yaml:"enabled" env:"TRACING_ENABLED"`
+		Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" default:"stdout"`
+		Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+		FilePath    string  `yaml:"file_path" env:"TRACING_FILE_PATH"`
+		SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
 	}
 )
 
-// Load creates a new container instance
-func Load() (*Config, error) {
-	if os.Getenv("APP_ENV") != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	app := &App{
-		Name:    os.Getenv("APP_NAME"),
-		Env:     os.Getenv("APP_ENV"),
-		Version: os.Getenv("APP_VERSION"),
-	}
-
-	token := &Token{
-		Duration: os.Getenv("TOKEN_DURATION"),
-	}
-
-	redis := &Redis{
-		Addr:     os.Getenv("REDIS_ADDR"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-	}
-
-	db := &DB{
-		Connection: os.Getenv("DB_CONNECTION"),
-		Host:       os.Getenv("DB_HOST"),
-		Port:       os.Getenv("DB_PORT"),
-		User:       os.Getenv("DB_USER"),
-		Password:   os.Getenv("DB_PASSWORD"),
-		Name:       os.Getenv("DB_NAME"),
-	}
-
-	http := &HTTP{
-		Env:            os.Getenv("APP_ENV"),
-		URL:            os.Getenv("HTTP_URL"),
-		Port:           os.Getenv("HTTP_PORT"),
-		AllowedOrigins: os.Getenv("HTTP_ALLOWED_ORIGINS"),
-	}
-
-	logMaxSize, _ := strconv.Atoi(os.Getenv("LOG_MAX_SIZE"))
-	logBackUps, _ := strconv.Atoi(os.Getenv("LOG_BACKUPS"))
-	logMaxAge, _ := strconv.Atoi(os.Getenv("LOG_MAX_AGE"))
-	logCompress, _ := strconv.ParseBool(os.Getenv("LOG_COMPRESS"))
-
-	logger := &Logger{
-		LogPath:     os.Getenv("LOG_PATH"),
-		LogLevel:    os.Getenv("LOG_LEVEL"),
-		LogMaxSize:  logMaxSize,
-		LogBackUps:  logBackUps,
-		LogMaxAge:   logMaxAge,
-		LogCompress: logCompress,
-	}
-
-	rps, _ := strconv.Atoi(os.Getenv("LIMITER_RPS"))
-	burst, _ := strconv.Atoi(os.Getenv("LIMITER_BURST"))
-	enabled, _ := strconv.ParseBool(os.Getenv("LIMITER_ENABLED"))
-	limiter := &Limiter{
-		Rps:     rps,
-		Burst:   burst,
-		Enabled: enabled,
-	}
+// args holds the command-line arguments of the last Load, so Reload can apply them
+// again, and file the config file they pointed at.
+var (
+	args []string
+	file string
+)
 
-	smtpPort, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
-	smtp := &SMTP{
-		Host:     os.Getenv("SMTP_HOST"),
-		Port:     smtpPort,
-		Username: os.Getenv("SMTP_USERNAME"),
-		Password: os.Getenv("SMTP_PASSWORD"),
-		Sender:   os.Getenv("SMTP_SENDER"),
-	}
+// Load builds the config from defaults, the config file, the environment (including a
+// .env file when one exists outside production) and the command-line arguments, then
+// validates it.
+func Load(arguments []string) (*Config, error) {
+	args = arguments
+	return load(false)
+}
 
-	return &Config{
-		app,
-		token,
-		redis,
-		db,
-		http,
-		logger,
-		limiter,
-		smtp,
-	}, nil
+// Reload builds the config again from the same sources, picking up changes to .env
+// outside production. Variables set in the real environment still win over .env.
+func Reload() (*Config, error) {
+	return load(true)
 }
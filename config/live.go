@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reloadable lists the settings that running components pick up without a restart,
+// by path or by section prefix. limiter.store is excluded because the limiter backend
+// is chosen at startup.
+var reloadable = []string{
+	"limiter.",
+	"http.allowed_origins",
+	"logger.level",
+	"logger.levels",
+}
+
+// Change describes one setting that differs between two configs. Applied is false for
+// settings that only take effect after a restart.
+type Change struct {
+	Path    string
+	From    string
+	To      string
+	Applied bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.From, c.To)
+}
+
+// Live holds the running config and swaps its reloadable settings in when the config
+// is reloaded. Components that cache derived state subscribe with OnChange.
+type Live struct {
+	mu        sync.Mutex
+	current   atomic.Pointer[Config]
+	listeners []func(*Config, []Change)
+}
+
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.current.Store(cfg)
+	return l
+}
+
+// Get returns the current config. It must be treated as read-only.
+func (l *Live) Get() *Config {
+	return l.current.Load()
+}
+
+// OnChange registers fn to be called with the new config and the changes after every
+// reload that changed a reloadable setting. Listeners use Applied to skip reloads
+// that did not touch their settings.
+func (l *Live) OnChange(fn func(*Config, []Change)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// Reload loads the config again and applies its reloadable settings. An invalid config
+// is rejected as a whole and the running config is kept. It returns every setting that
+// changed, including the ones that need a restart.
+func (l *Live) Reload() ([]Change, error) {
+	fresh, err := Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := l.Get()
+	changes := diff(current, fresh)
+	if !anyApplied(changes) {
+		return changes, nil
+	}
+
+	next := *current
+	limiter := *fresh.Limiter
+	limiter.Store = current.Limiter.Store
+	next.Limiter = &limiter
+	http := *current.HTTP
+	http.AllowedOrigins = fresh.HTTP.AllowedOrigins
+	next.HTTP = &http
+	logger := *current.Logger
+	logger.LogLevel = fresh.Logger.LogLevel
+	logger.LogLevels = fresh.Logger.LogLevels
+	next.Logger = &logger
+
+	l.current.Store(&next)
+	for _, fn := range l.listeners {
+		fn(&next, changes)
+	}
+	return changes, nil
+}
+
+// Watch reloads the config whenever the config file given at startup changes, checking
+// every interval, and passes the outcome to onReload. It does nothing without a file.
+func (l *Live) Watch(interval time.Duration, onReload func([]Change, error)) {
+	if file == "" {
+		return
+	}
+	modTime := fileModTime(file)
+	go func() {
+		for {
+			time.Sleep(interval)
+			latest := fileModTime(file)
+			if latest.Equal(modTime) {
+				continue
+			}
+			modTime = latest
+			onReload(l.Reload())
+		}
+	}()
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func diff(from, to *Config) []Change {
+	var changes []Change
+	toFields := collectFields(to)
+	for i, f := range collectFields(from) {
+		if reflect.DeepEqual(f.value.Interface(), toFields[i].value.Interface()) {
+			continue
+		}
+		changes = append(changes, Change{
+			Path:    f.path,
+			From:    formatValue(f),
+			To:      formatValue(toFields[i]),
+			Applied: isReloadable(f.path),
+		})
+	}
+	return changes
+}
+
+func formatValue(f field) string {
+	if f.secret {
+		if f.value.Len() == 0 {
+			return `""`
+		}
+		return maskedSecret
+	}
+	if f.value.Kind() == reflect.Slice {
+		return fmt.Sprintf("%q", f.value.Interface())
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+func isReloadable(path string) bool {
+	if path == "limiter.store" {
+		return false
+	}
+	for _, r := range reloadable {
+		if path == r || (strings.HasSuffix(r, ".") && strings.HasPrefix(path, r)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Applied reports whether any of the settings at paths was changed and applied.
+func Applied(changes []Change, paths ...string) bool {
+	for _, c := range changes {
+		if c.Applied && slices.Contains(paths, c.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyApplied(changes []Change) bool {
+	for _, c := range changes {
+		if c.Applied {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFileFlag  = "config"
+	configFileEnv   = "CONFIG_FILE"
+	printConfigFlag = "print-config"
+	secretFileEnv   = "_FILE"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a single setting of the config, addressed by its dotted path in the config
+// file and on the command line, and by its environment variable.
+type field struct {
+	path   string
+	env    string
+	def    string
+	secret bool
+	value  reflect.Value
+}
+
+func load(reload bool) (*Config, error) {
+	if err := loadDotEnv(reload); err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		App:     &App{},
+		Token:   &Token{},
+		Redis:   &Redis{},
+		DB:      &DB{},
+		HTTP:    &HTTP{},
+		Logger:  &Logger{},
+		Limiter: &Limiter{},
+		Smtp:    &SMTP{},
+		Login:   &Login{},
+		OIDC:    &OIDC{},
+		Access:  &Access{},
+		Tracing: &Tracing{},
+	}
+	fields := collectFields(cfg)
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := flags.String(configFileFlag, os.Getenv(configFileEnv), "path to a YAML or TOML config file (env "+configFileEnv+")")
+	flags.BoolVar(&cfg.PrintConfig, printConfigFlag, false, "print the effective config with secrets masked and exit")
+	byPath := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byPath[f.path] = f
+		flags.String(f.path, "", "overrides $"+f.env)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.Args = flags.Args()
+
+	var errs []error
+	for _, f := range fields {
+		if f.def != "" {
+			errs = append(errs, f.set(f.def, "default"))
+		}
+	}
+
+	file = *configFile
+	if *configFile != "" {
+		values, err := readConfigFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		for path, value := range values {
+			f, ok := byPath[path]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %s", *configFile, path))
+				continue
+			}
+			errs = append(errs, f.set(value, *configFile))
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := os.LookupEnv(f.env); ok {
+			errs = append(errs, f.set(value, "$"+f.env))
+		}
+		if !f.secret {
+			continue
+		}
+		if path, ok := os.LookupEnv(f.env + secretFileEnv); ok {
+			secret, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("$%s: %w", f.env+secretFileEnv, err))
+				continue
+			}
+			errs = append(errs, f.set(strings.TrimRight(string(secret), "\r\n"), "$"+f.env+secretFileEnv))
+		}
+	}
+
+	flags.Visit(func(fl *flag.Flag) {
+		if f, ok := byPath[fl.Name]; ok {
+			errs = append(errs, f.set(fl.Value.String(), "--"+fl.Name))
+		}
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	cfg.Logger.Env = cfg.App.Env
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// realEnv holds the environment variables set before .env was first read. They win
+// over .env on every load, like godotenv.Load gives them at startup.
+var realEnv map[string]bool
+
+// loadDotEnv reads .env outside production. A missing file is not an error. On reload
+// the values in .env replace the ones it set before, but never a variable that was set
+// in the real environment.
+func loadDotEnv(reload bool) error {
+	if os.Getenv("APP_ENV") == "production" {
+		return nil
+	}
+	if !reload {
+		realEnv = make(map[string]bool)
+		for _, kv := range os.Environ() {
+			key, _, _ := strings.Cut(kv, "=")
+			realEnv[key] = true
+		}
+		if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	values, err := godotenv.Read()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for key, value := range values {
+		if realEnv[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectFields lists every setting of cfg, in declaration order.
+func collectFields(cfg *Config) []field {
+	var fields []field
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i)
+		prefix := yamlName(section)
+		if prefix == "" || section.Type.Kind() != reflect.Pointer {
+			continue
+		}
+		group := root.Field(i).Elem()
+		for j := 0; j < group.NumField(); j++ {
+			setting := group.Type().Field(j)
+			env := setting.Tag.Get("env")
+			if env == "" {
+				continue
+			}
+			fields = append(fields, field{
+				path:   prefix + "." + yamlName(setting),
+				env:    env,
+				def:    setting.Tag.Get("default"),
+				secret: setting.Tag.Get("secret") == "true",
+				value:  group.Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// set parses value into the field according to its type. source names where the value
+// came from in the error.
+func (f field) set(value, source string) error {
+	invalid := func(kind string) error {
+		return fmt.Errorf("%s: %s must be %s, got %q", source, f.path, kind, value)
+	}
+
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return invalid("a duration such as 15m")
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(value)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return invalid("an integer")
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid("a boolean")
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalid("a number")
+		}
+		f.value.SetFloat(n)
+	case f.value.Kind() == reflect.Slice:
+		f.value.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("%s: unsupported setting type %s", f.path, f.value.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, dropping blank entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readConfigFile reads a YAML or TOML file, chosen by extension, into settings keyed
+// by their dotted path.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: config file must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, raw map[string]any, values map[string]string) {
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch value := raw[key].(type) {
+		case map[string]any:
+			flatten(path, value, values)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[path] = strings.Join(items, ",")
+		case nil:
+		default:
+			values[path] = fmt.Sprint(value)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const maskedSecret = "******"
+
+// WriteMasked writes the effective config as YAML, in the format accepted by --config,
+// with every secret that is set replaced by a mask.
+func (c *Config) WriteMasked(w io.Writer) error {
+	masked := *c
+	root := reflect.ValueOf(&masked).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		if section.Kind() != reflect.Pointer || section.IsNil() {
+			continue
+		}
+		copied := reflect.New(section.Elem().Type())
+		copied.Elem().Set(section.Elem())
+		section.Set(copied)
+	}
+	for _, f := range collectFields(&masked) {
+		if !f.secret || f.value.Len() == 0 {
+			continue
+		}
+		if f.value.Kind() == reflect.Slice {
+			// A new slice, as the copied section still shares the original's backing array
+			list := make([]string, f.value.Len())
+			for i := range list {
+				list[i] = maskedSecret
+			}
+			f.value.Set(reflect.ValueOf(list))
+			continue
+		}
+		f.value.SetString(maskedSecret)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&masked); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
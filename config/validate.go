@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+var (
+	environments = []string{"development", "test", "staging", "production"}
+	logLevels    = []string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}
+	sslModes     = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+)
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.App.Name != "", "app.name is required")
+	check(oneOf(c.App.Env, environments), "app.env must be one of %s", strings.Join(environments, ", "))
+
+	check(c.Token.Duration > 0, "token.duration must be positive")
+
+	check(c.DB.Host != "", "db.host is required")
+	check(validPort(c.DB.Port), "db.port must be between 1 and 65535")
+	check(c.DB.User != "", "db.user is required")
+	check(c.DB.Name != "", "db.name is required")
+	check(oneOf(c.DB.SSLMode, sslModes), "db.sslmode must be one of %s", strings.Join(sslModes, ", "))
+	check(c.DB.MaxConns > 0, "db.max_conns must be positive")
+	check(c.DB.MinConns >= 0 && c.DB.MinConns <= c.DB.MaxConns, "db.min_conns must be between 0 and db.max_conns")
+	check(c.DB.MaxConnLifetime >= 0 && c.DB.MaxConnIdleTime >= 0, "db.max_conn_lifetime and db.max_conn_idle_time must not be negative")
+	check(c.DB.HealthCheckPeriod > 0, "db.health_check_period must be positive")
+	check(c.DB.StatementTimeout >= 0 && c.DB.QueryTimeout >= 0, "db.statement_timeout and db.query_timeout must not be negative")
+	for i, replica := range c.DB.Replicas {
+		check(validURL(replica), "db.replicas: entry %d must be a postgres:// URL", i+1)
+	}
+	check(c.DB.ReplicaMaxLag > 0 && c.DB.ReplicaCheckInterval > 0, "db.replica_max_lag and db.replica_check_interval must be positive")
+
+	check(c.HTTP.URL == "" || validURL(c.HTTP.URL), "http.url must be an absolute URL")
+	check(validPort(c.HTTP.Port), "http.port must be between 1 and 65535")
+	check(len(c.HTTP.AllowedOrigins) > 0, "http.allowed_origins is required")
+	for _, origin := range c.HTTP.AllowedOrigins {
+		check(origin == "*" || validOrigin(origin), "http.allowed_origins: %q must be * or an http or https origin", origin)
+	}
+	for _, proxy := range c.HTTP.TrustedProxies {
+		check(validNetwork(proxy), "http.trusted_proxies: %q is not a CIDR or address", proxy)
+	}
+	check(oneOf(c.HTTP.ForwardedHeader, []string{"x-forwarded-for", "forwarded"}), "http.forwarded_header must be x-forwarded-for or forwarded")
+
+	check(oneOf(c.Logger.LogLevel, logLevels), "logger.level must be one of %s", strings.Join(logLevels, ", "))
+	for _, pair := range splitList(c.Logger.LogLevels) {
+		_, level, ok := strings.Cut(pair, "=")
+		check(ok && oneOf(strings.TrimSpace(level), logLevels), "logger.levels: %q must be name=level", pair)
+	}
+	for _, output := range c.Logger.Outputs {
+		check(oneOf(output, []string{"stdout", "file"}), "logger.outputs: %q must be stdout or file", output)
+		check(output != "file" || c.Logger.LogPath != "", "logger.path is required when logging to a file")
+	}
+	check(oneOf(c.Logger.StdoutFormat, []string{"", "console", "json"}), "logger.stdout_format must be console or json")
+	check(c.Logger.SamplingInitial >= 0 && c.Logger.SamplingThereafter >= 0, "logger sampling must not be negative")
+
+	check(c.Limiter.Rps >= 0 && c.Limiter.Burst >= 0, "limiter.rps and limiter.burst must not be negative")
+	check(c.Limiter.AuthRps >= 0 && c.Limiter.AuthBurst >= 0, "limiter.auth_rps and limiter.auth_burst must not be negative")
+	check(c.Limiter.IPRps >= 0 && c.Limiter.IPBurst >= 0, "limiter.ip_rps and limiter.ip_burst must not be negative")
+	check(oneOf(c.Limiter.Store, []string{"memory", "redis"}), "limiter.store must be memory or redis")
+	check(c.Limiter.Store != "redis" || c.Redis.Addr != "", "redis.addr is required when limiter.store is redis")
+
+	check(validPort(c.Smtp.Port), "smtp.port must be between 1 and 65535")
+
+	check(c.Login.MaxAccountAttempts > 0, "login.max_account_attempts must be positive")
+	check(c.Login.MaxIPAttempts > 0, "login.max_ip_attempts must be positive")
+	check(c.Login.Window > 0, "login.window must be positive")
+	check(c.Login.LockoutDuration > 0, "login.lockout_duration must be positive")
+	check(c.Login.BaseDelay > 0 && c.Login.BaseDelay <= c.Login.MaxDelay, "login.base_delay must be positive and at most login.max_delay")
+
+	if c.OIDC.Enabled {
+		check(validURL(c.OIDC.Issuer), "oidc.issuer must be an absolute URL")
+		check(c.OIDC.ClientID != "", "oidc.client_id is required")
+		check(validURL(c.OIDC.RedirectURL), "oidc.redirect_url must be an absolute URL")
+	}
+
+	check(c.Access.ReloadInterval > 0, "access.reload_interval must be positive")
+
+	check(oneOf(c.Tracing.Exporter, []string{"otlp", "stdout"}), "tracing.exporter must be otlp or stdout")
+	check(c.Tracing.Endpoint == "" || validURL(c.Tracing.Endpoint), "tracing.endpoint must be an absolute URL")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func validOrigin(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validNetwork(value string) bool {
+	if _, err := netip.ParsePrefix(value); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(value)
+	return err == nil
+}
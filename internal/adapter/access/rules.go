@@ -0,0 +1,186 @@
+package access
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/metrics"
+)
+
+// Route groups the access middleware is applied to.
+const (
+	GroupDefault = "default"
+	GroupAdmin   = "admin"
+	GroupMetrics = "metrics"
+)
+
+// Rule is the allow and deny CIDR lists of one route group as written in the rules
+// file, for example:
+//
+//	{"default": {"deny": ["203.0.113.0/24"]}, "admin": {"allow": ["10.0.0.0/8"]}}
+type Rule struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+type ruleSet struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// Rules holds the access rules of every route group. The rules file is polled and
+// swapped in when it changes; a file that fails to parse is logged and the previous
+// rules are kept.
+type Rules struct {
+	mu      sync.RWMutex
+	groups  map[string]ruleSet
+	path    string
+	modTime time.Time
+}
+
+func NewRules(cfg *config.Access) (*Rules, error) {
+	r := &Rules{
+		groups: make(map[string]ruleSet),
+		path:   cfg.RulesFile,
+	}
+	if r.path == "" {
+		return r, nil
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	interval := cfg.ReloadInterval
+	go func() {
+		for {
+			time.Sleep(interval)
+			if err := r.load(); err != nil {
+				logger.Error("failed to reload access rules", "path", r.path, "err", err)
+			}
+		}
+	}()
+
+	return r, nil
+}
+
+// Allow reports whether ip may reach the routes of group. Deny rules win over allow
+// rules, and a group with allow rules rejects every address not covered by them,
+// including addresses that cannot be parsed.
+func (r *Rules) Allow(group, ip string) bool {
+	r.mu.RLock()
+	rules, ok := r.groups[group]
+	r.mu.RUnlock()
+	if !ok {
+		return true
+	}
+
+	allowed := rules.allows(ip)
+	if !allowed {
+		metrics.AccessBlocked.WithLabelValues(group).Inc()
+	}
+	return allowed
+}
+
+func (s ruleSet) allows(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return len(s.allow) == 0
+	}
+	addr = addr.Unmap()
+	if containsAddr(s.deny, addr) {
+		return false
+	}
+	return len(s.allow) == 0 || containsAddr(s.allow, addr)
+}
+
+func (r *Rules) load() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(r.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	var raw map[string]Rule
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid access rules file: %w", err)
+	}
+
+	groups := make(map[string]ruleSet, len(raw))
+	for group, rule := range raw {
+		var set ruleSet
+		if set.allow, err = ParsePrefixes(rule.Allow); err != nil {
+			return fmt.Errorf("group %s: %w", group, err)
+		}
+		if set.deny, err = ParsePrefixes(rule.Deny); err != nil {
+			return fmt.Errorf("group %s: %w", group, err)
+		}
+		groups[group] = set
+	}
+
+	r.mu.Lock()
+	r.groups = groups
+	r.mu.Unlock()
+	r.modTime = info.ModTime()
+	logger.Info("loaded access rules", "path", r.path, "groups", len(groups))
+	return nil
+}
+
+// ParsePrefixes parses CIDRs and bare addresses, treating the latter as single host
+// prefixes. Blank entries are skipped.
+func ParsePrefixes(entries []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", entry, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+func parsePrefix(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if addr.Zone() != "" {
+		return netip.Prefix{}, errors.New("zoned addresses are not supported")
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
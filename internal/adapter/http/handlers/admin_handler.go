@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/util"
+)
+
+type AdminHandler struct {
+	wg                *sync.WaitGroup
+	userService       ports.UserService
+	permissionService ports.PermissionService
+	mailerService     ports.MailerService
+}
+
+func NewAdminHandler(
+	wg *sync.WaitGroup,
+	userService ports.UserService,
+	permissionService ports.PermissionService,
+	mailerService ports.MailerService,
+) *AdminHandler {
+	return &AdminHandler{
+		wg:                wg,
+		userService:       userService,
+		permissionService: permissionService,
+		mailerService:     mailerService,
+	}
+}
+
+type (
+	listUserRequest struct {
+		Search string `form:"search"`
+		domain.Filter
+	}
+	permissionsRequest struct {
+		Permissions []string `json:"permissions" binding:"required,min=1,dive,oneof=movies:read movies:write admin"`
+	}
+)
+
+func (h *AdminHandler) ListUsers(ctx *gin.Context) {
+	var queryParams listUserRequest
+	queryParams.SortSafeList = []string{"id", "-id", "name", "-name", "email", "-email", "created_at", "-created_at"}
+	if err := ctx.ShouldBindQuery(&queryParams); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	filter := domain.Filter{
+		Page:         util.ReadInt(queryParams.Page, 1),
+		Size:         util.ReadInt(queryParams.Size, 10),
+		Sort:         ctx.DefaultQuery("sort", "id"),
+		SortSafeList: queryParams.SortSafeList,
+	}
+
+	users, metadata, err := h.userService.GetAllUsers(ctx, queryParams.Search, filter)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+
+	SendSuccess(ctx, Envelope{
+		"users":    users,
+		"metadata": metadata,
+	})
+}
+
+func (h *AdminHandler) ShowUser(ctx *gin.Context) {
+	var req params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user, err := h.userService.GetUserByID(ctx, req.ID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	permissions, err := h.permissionService.GetPermissionsForUser(ctx, user.ID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendSuccess(ctx, Envelope{
+		"user":        user,
+		"permissions": permissions,
+	})
+}
+
+func (h *AdminHandler) ActivateUser(ctx *gin.Context) {
+	h.setUserActivated(ctx, true)
+}
+
+func (h *AdminHandler) DeactivateUser(ctx *gin.Context) {
+	h.setUserActivated(ctx, false)
+}
+
+func (h *AdminHandler) setUserActivated(ctx *gin.Context, activated bool) {
+	var req params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user, err := h.userService.GetUserByID(ctx, req.ID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	if err = h.userService.SetUserActivated(ctx, user, activated); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendUpdatedSuccess(ctx, Envelope{
+		"user": user,
+	})
+}
+
+func (h *AdminHandler) GrantPermissions(ctx *gin.Context) {
+	var param params
+	if err := ctx.ShouldBindUri(&param); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	var req permissionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	if _, err := h.userService.GetUserByID(ctx, param.ID); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	if err := h.permissionService.GrantPermissions(ctx, param.ID, req.Permissions...); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	h.sendPermissions(ctx, param.ID)
+}
+
+func (h *AdminHandler) RevokePermissions(ctx *gin.Context) {
+	var param params
+	if err := ctx.ShouldBindUri(&param); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	var req permissionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	if _, err := h.userService.GetUserByID(ctx, param.ID); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	if err := h.permissionService.RevokePermissions(ctx, param.ID, req.Permissions...); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	h.sendPermissions(ctx, param.ID)
+}
+
+func (h *AdminHandler) sendPermissions(ctx *gin.Context, userID int64) {
+	permissions, err := h.permissionService.GetPermissionsForUser(ctx, userID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendUpdatedSuccess(ctx, Envelope{
+		"permissions": permissions,
+	})
+}
+
+func (h *AdminHandler) SendPasswordReset(ctx *gin.Context) {
+	var req params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user, err := h.userService.GetUserByID(ctx, req.ID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	token, err := h.userService.CreatePasswordResetToken(ctx, user)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	util.Background(ctx, h.wg, func(ctx context.Context) {
+		data := map[string]any{
+			"userID": user.ID,
+			"token":  token.Plaintext,
+		}
+		if err := h.mailerService.Send(ctx, user.Email, "user_password_reset.tmpl", data); err != nil {
+			logger.FromContext(ctx).Named(logger.Mailer).Error("failed to send password reset email", "msg", err)
+		}
+	})
+
+	SendAcceptedSuccess(ctx, Envelope{
+		"message": "a password reset email will be sent to the user",
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/ports"
+)
+
+type APIKeyHandler struct {
+	apiKeyService ports.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService ports.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type (
+	createAPIKeyRequest struct {
+		Name        string     `json:"name" binding:"required,max=100"`
+		Permissions []string   `json:"permissions" binding:"omitempty,dive,oneof=movies:read movies:write admin"`
+		ExpiresAt   *time.Time `json:"expires_at"`
+	}
+)
+
+func (h *APIKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+	key, err := h.apiKeyService.CreateAPIKey(ctx, GetContextUser(ctx), req.Name, req.Permissions, req.ExpiresAt)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendCreatedSuccess(ctx, Envelope{
+		"api_key": key,
+	})
+}
+
+func (h *APIKeyHandler) ListAPIKeys(ctx *gin.Context) {
+	keys, err := h.apiKeyService.ListAPIKeys(ctx, GetContextUser(ctx).ID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendSuccess(ctx, Envelope{
+		"api_keys": keys,
+	})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	var req params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	if err := h.apiKeyService.RevokeAPIKey(ctx, GetContextUser(ctx).ID, req.ID); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendDeletedSuccess(ctx)
+}
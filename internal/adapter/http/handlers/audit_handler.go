@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/util"
+)
+
+type AuditHandler struct {
+	auditService ports.AuditService
+}
+
+func NewAuditHandler(auditService ports.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+type (
+	listAuditEventRequest struct {
+		ActorID int64      `form:"actor_id" binding:"omitempty,min=1"`
+		Action  string     `form:"action"`
+		From    *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+		To      *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+		domain.Filter
+	}
+)
+
+func (h *AuditHandler) ListEvents(ctx *gin.Context) {
+	var queryParams listAuditEventRequest
+	queryParams.SortSafeList = []string{"id", "-id", "created_at", "-created_at", "action", "-action"}
+	if err := ctx.ShouldBindQuery(&queryParams); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	auditFilter := domain.AuditFilter{
+		ActorID: queryParams.ActorID,
+		Action:  queryParams.Action,
+		From:    queryParams.From,
+		To:      queryParams.To,
+	}
+	filter := domain.Filter{
+		Page:         util.ReadInt(queryParams.Page, 1),
+		Size:         util.ReadInt(queryParams.Size, 10),
+		Sort:         ctx.DefaultQuery("sort", "-created_at"),
+		SortSafeList: queryParams.SortSafeList,
+	}
+
+	events, metadata, err := h.auditService.GetAllEvents(ctx, auditFilter, filter)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+
+	SendSuccess(ctx, Envelope{
+		"audit_events": events,
+		"metadata":     metadata,
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/pkg/logger"
+)
+
+const (
+	userContextKey      = "user"
+	apiKeyContextKey    = "api_key"
+	clientIPContextKey  = "client_ip"
+	requestIDContextKey = "request_id"
+)
+
+// SetContextUser stores the user resolved by the authentication middleware on the
+// request context.
+func SetContextUser(ctx *gin.Context, user *domain.User) {
+	ctx.Set(userContextKey, user)
+	if !user.IsAnonymous() {
+		ctx.Request = ctx.Request.WithContext(logger.With(ctx.Request.Context(), "user_id", user.ID))
+	}
+}
+
+// GetContextUser returns the user for the current request, or domain.AnonymousUser
+// when the request was not authenticated.
+func GetContextUser(ctx *gin.Context) *domain.User {
+	user, ok := ctx.Get(userContextKey)
+	if !ok {
+		return domain.AnonymousUser
+	}
+	return user.(*domain.User)
+}
+
+// SetContextAPIKey records the API key a request was authenticated with.
+func SetContextAPIKey(ctx *gin.Context, key *domain.APIKey) {
+	ctx.Set(apiKeyContextKey, key)
+}
+
+// GetContextAPIKey returns the API key used by the current request, or nil when the
+// request was not authenticated with one.
+func GetContextAPIKey(ctx *gin.Context) *domain.APIKey {
+	key, ok := ctx.Get(apiKeyContextKey)
+	if !ok {
+		return nil
+	}
+	return key.(*domain.APIKey)
+}
+
+// SetContextClientIP records the client address resolved by the client IP middleware.
+func SetContextClientIP(ctx *gin.Context, ip string) {
+	ctx.Set(clientIPContextKey, ip)
+}
+
+// GetContextClientIP returns the resolved client address, falling back to gin's own
+// resolution when the client IP middleware did not run.
+func GetContextClientIP(ctx *gin.Context) string {
+	ip, ok := ctx.Get(clientIPContextKey)
+	if !ok {
+		return ctx.ClientIP()
+	}
+	return ip.(string)
+}
+
+// SetContextRequestID records the ID assigned to the current request.
+func SetContextRequestID(ctx *gin.Context, requestID string) {
+	ctx.Set(requestIDContextKey, requestID)
+}
+
+// GetContextRequestID returns the ID of the current request, or an empty string when
+// the request ID middleware did not run.
+func GetContextRequestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDContextKey)
+}
@@ -26,6 +26,19 @@ func (h *HealthHandler) Check(ctx *gin.Context) {
 			"version":     status.Version,
 		},
 	}
+	if len(status.Replicas) > 0 {
+		// Replicas are listed by their position in the config, as their addresses are
+		// not for public eyes.
+		replicas := make([]Envelope, 0, len(status.Replicas))
+		for i, replica := range status.Replicas {
+			replicas = append(replicas, Envelope{
+				"index":       i,
+				"healthy":     replica.Healthy,
+				"lag_seconds": replica.Lag.Seconds(),
+			})
+		}
+		resp["replicas"] = replicas
+	}
 	time.Sleep(4 * time.Second)
 	SendSuccess(ctx, resp)
 }
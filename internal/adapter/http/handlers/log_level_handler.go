@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/pkg/logger"
+)
+
+type LogLevelHandler struct{}
+
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{}
+}
+
+type setLogLevelRequest struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level" binding:"required"`
+}
+
+func (h *LogLevelHandler) ShowLevels(ctx *gin.Context) {
+	SendSuccess(ctx, Envelope{
+		"levels": logger.Levels(),
+	})
+}
+
+// SetLevel changes the level of the root logger, or of the sub-logger named in the
+// request, until the next restart or SIGHUP.
+func (h *LogLevelHandler) SetLevel(ctx *gin.Context) {
+	var req setLogLevelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	if err := logger.SetLevel(req.Logger, req.Level); err != nil {
+		HandleError(ctx, fmt.Errorf("%w: %v", domain.ErrorValidation, err))
+		return
+	}
+	SendUpdatedSuccess(ctx, Envelope{
+		"levels": logger.Levels(),
+	})
+}
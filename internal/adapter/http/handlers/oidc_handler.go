@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+)
+
+type OIDCHandler struct {
+	oidcService ports.OIDCService
+}
+
+func NewOIDCHandler(oidcService ports.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+type (
+	oidcCallbackRequest struct {
+		State string `form:"state" binding:"required"`
+		Code  string `form:"code" binding:"required"`
+	}
+)
+
+func (h *OIDCHandler) Login(ctx *gin.Context) {
+	url, err := h.oidcService.BeginLogin(ctx)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	ctx.Redirect(http.StatusFound, url)
+}
+
+func (h *OIDCHandler) Callback(ctx *gin.Context) {
+	var req oidcCallbackRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	token, err := h.oidcService.CompleteLogin(ctx, req.State, req.Code, GetContextClientIP(ctx))
+	if err != nil {
+		var retryErr *domain.RetryAfterError
+		if errors.As(err, &retryErr) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+		}
+		HandleError(ctx, err)
+		return
+	}
+	if token.Scope == domain.ScopeTwoFactor {
+		SendAcceptedSuccess(ctx, Envelope{
+			"two_factor_required": true,
+			"two_factor_token":    token,
+		})
+		return
+	}
+	SendCreatedSuccess(ctx, Envelope{
+		"authentication_token": token,
+	})
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/pkg/logger"
 	"github.com/thaian1234/green_light/pkg/util"
 )
 
@@ -15,9 +17,10 @@ type (
 		Data    any    `json:"data,omitempty"`
 	}
 	ErrorResponse struct {
-		Success bool   `json:"success"`
-		Message string `json:"message,omitempty"`
-		Errors  any    `json:"errors,omitempty"`
+		Success   bool   `json:"success"`
+		Message   string `json:"message,omitempty"`
+		Errors    any    `json:"errors,omitempty"`
+		RequestID string `json:"request_id,omitempty"`
 	}
 	Envelope map[string]any
 )
@@ -32,9 +35,18 @@ var errorStatusMap = map[error]int{
 	domain.ErrExpiredToken:       http.StatusUnauthorized,
 	domain.ErrForbidden:          http.StatusForbidden,
 	domain.ErrNoUpdatedData:      http.StatusBadRequest,
+	domain.ErrUpdateConflict:     http.StatusConflict,
 	domain.ErrorValidation:       http.StatusUnprocessableEntity,
 	domain.ErrConflictingData:    http.StatusConflict,
 	domain.ErrDuplicatedEmail:    http.StatusConflict,
+	domain.ErrInactiveAccount:    http.StatusForbidden,
+	domain.ErrAccountLocked:      http.StatusForbidden,
+	domain.ErrTooManyAttempts:    http.StatusTooManyRequests,
+	domain.ErrUnverifiedIdentity: http.StatusForbidden,
+	domain.ErrIdentityProvider:   http.StatusBadGateway,
+	domain.ErrRateLimitExceeded:  http.StatusTooManyRequests,
+	domain.ErrServiceUnavailable: http.StatusServiceUnavailable,
+	domain.ErrQueryTimeout:       http.StatusGatewayTimeout,
 }
 
 func newResponse(message string, data any) Response {
@@ -59,29 +71,69 @@ func HandleValidationError(ctx *gin.Context, err error) {
 	ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, errRsp)
 }
 
+// statusFor returns the HTTP status mapped to err and the domain error it matched,
+// also matching domain errors that have been wrapped.
+func statusFor(err error) (int, error, bool) {
+	if statusCode, ok := errorStatusMap[err]; ok {
+		return statusCode, err, true
+	}
+	for domainErr, statusCode := range errorStatusMap {
+		if errors.Is(err, domainErr) {
+			return statusCode, domainErr, true
+		}
+	}
+	return http.StatusInternalServerError, domain.ErrInternalServer, false
+}
+
+// errorDetails returns the messages reported to the client for err. Server errors
+// only report the domain error they matched, since the wrapped cause may expose
+// database internals.
+func errorDetails(err, domainErr error, statusCode int) map[string]string {
+	var fieldErr *domain.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		return map[string]string{fieldErr.Field: fieldErr.Message}
+	case statusCode >= http.StatusInternalServerError:
+		return util.ParseError(domainErr)
+	default:
+		return util.ParseError(err)
+	}
+}
+
 func HandleError(ctx *gin.Context, err error) {
-	errMsg := util.ParseError(err)
 	msg := "Failed to process request"
-	statusCode, ok := errorStatusMap[err]
+	statusCode, domainErr, ok := statusFor(err)
 	if !ok {
-		statusCode = http.StatusInternalServerError
 		msg = "Internal server error"
 	}
-	errResponse := newErrorResponse(msg, errMsg)
+	errResponse := newErrorResponse(msg, errorDetails(err, domainErr, statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		errResponse.RequestID = logServerError(ctx, err)
+	}
 	ctx.JSON(statusCode, errResponse)
 }
 
 func HandleAbort(ctx *gin.Context, err error) {
-	statusCode, ok := errorStatusMap[err]
-	msg := err.Error()
+	statusCode, domainErr, ok := statusFor(err)
+	msg := domainErr.Error()
 	if !ok {
-		statusCode = http.StatusInternalServerError
 		msg = "Internal server error"
 	}
 	errResponse := newErrorResponse(msg, err)
+	if statusCode >= http.StatusInternalServerError {
+		errResponse.Errors = nil
+		errResponse.RequestID = logServerError(ctx, err)
+	}
 	ctx.AbortWithStatusJSON(statusCode, errResponse)
 }
 
+// logServerError logs err with the request's context and returns the request ID, so
+// clients can quote it when reporting the failure.
+func logServerError(ctx *gin.Context, err error) string {
+	logger.FromContext(ctx).Error("request failed", "err", err)
+	return GetContextRequestID(ctx)
+}
+
 func SendSuccess(ctx *gin.Context, data any) {
 	response := newResponse("Operation successful", data)
 	ctx.JSON(http.StatusOK, response)
@@ -92,6 +144,11 @@ func SendCreatedSuccess(ctx *gin.Context, data any) {
 	ctx.JSON(http.StatusCreated, response)
 }
 
+func SendAcceptedSuccess(ctx *gin.Context, data any) {
+	response := newResponse("Request accepted for processing", data)
+	ctx.JSON(http.StatusAccepted, response)
+}
+
 func SendDeletedSuccess(ctx *gin.Context) {
 	response := newResponse("Resource deleted successfully", nil)
 	ctx.JSON(http.StatusOK, response)
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/util"
+)
+
+type TokenHandler struct {
+	wg            *sync.WaitGroup
+	tokenService  ports.TokenService
+	mailerService ports.MailerService
+}
+
+func NewTokenHandler(wg *sync.WaitGroup, tokenService ports.TokenService, mailerService ports.MailerService) *TokenHandler {
+	return &TokenHandler{
+		wg:            wg,
+		tokenService:  tokenService,
+		mailerService: mailerService,
+	}
+}
+
+type (
+	createAuthenticationTokenRequest struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+	completeTwoFactorLoginRequest struct {
+		Token string `json:"token" binding:"required"`
+		Code  string `json:"code" binding:"required"`
+	}
+)
+
+func (h *TokenHandler) CreateAuthenticationToken(ctx *gin.Context) {
+	var req createAuthenticationTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	token, err := h.tokenService.CreateAuthenticationToken(ctx, req.Email, req.Password, GetContextClientIP(ctx))
+	if err != nil {
+		h.handleLoginError(ctx, err)
+		return
+	}
+	if token.Scope == domain.ScopeTwoFactor {
+		SendAcceptedSuccess(ctx, Envelope{
+			"two_factor_required": true,
+			"two_factor_token":    token,
+		})
+		return
+	}
+	SendCreatedSuccess(ctx, Envelope{
+		"authentication_token": token,
+	})
+}
+
+func (h *TokenHandler) CompleteTwoFactorLogin(ctx *gin.Context) {
+	var req completeTwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	token, err := h.tokenService.CompleteTwoFactorLogin(ctx, req.Token, req.Code, GetContextClientIP(ctx))
+	if err != nil {
+		h.handleLoginError(ctx, err)
+		return
+	}
+	SendCreatedSuccess(ctx, Envelope{
+		"authentication_token": token,
+	})
+}
+
+func (h *TokenHandler) handleLoginError(ctx *gin.Context, err error) {
+	var (
+		retryErr  *domain.RetryAfterError
+		lockedErr *domain.AccountLockedError
+	)
+	switch {
+	case errors.As(err, &retryErr):
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+	case errors.As(err, &lockedErr):
+		// Only the owner learns about the lock; the client sees the usual failure.
+		util.Background(ctx, h.wg, func(ctx context.Context) {
+			data := map[string]any{
+				"email": lockedErr.Email,
+			}
+			if err := h.mailerService.Send(ctx, lockedErr.Email, "user_account_locked.tmpl", data); err != nil {
+				logger.FromContext(ctx).Named(logger.Mailer).Error("failed to send account locked email", "msg", err)
+			}
+		})
+		err = domain.ErrInvalidCredentials
+	}
+	HandleError(ctx, err)
+}
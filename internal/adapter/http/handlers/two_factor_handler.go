@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService ports.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService ports.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+type (
+	beginTwoFactorRequest struct {
+		Password string `json:"password" binding:"required"`
+	}
+	twoFactorCodeRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+)
+
+func (h *TwoFactorHandler) BeginEnrolment(ctx *gin.Context) {
+	var req beginTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user := GetContextUser(ctx)
+	match, err := user.PasswordMatches(req.Password)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	if !match {
+		HandleError(ctx, domain.ErrInvalidCredentials)
+		return
+	}
+	enrolment, err := h.twoFactorService.BeginEnrolment(ctx, user)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendCreatedSuccess(ctx, Envelope{
+		"two_factor": enrolment,
+	})
+}
+
+func (h *TwoFactorHandler) ConfirmEnrolment(ctx *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	codes, err := h.twoFactorService.ConfirmEnrolment(ctx, GetContextUser(ctx), req.Code)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendUpdatedSuccess(ctx, Envelope{
+		"recovery_codes": codes,
+	})
+}
+
+func (h *TwoFactorHandler) Disable(ctx *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	if err := h.twoFactorService.Disable(ctx, GetContextUser(ctx), req.Code); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendDeletedSuccess(ctx)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"sync"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +31,25 @@ type (
 		Email    string `json:"email" binding:"required,email"`
 		Password string `json:"password" binding:"required"`
 	}
+	changeEmailRequest struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+	confirmEmailChangeRequest struct {
+		Token string `json:"token" binding:"required"`
+	}
+	updateUserRequest struct {
+		Name            *string `json:"name" binding:"omitempty,required"`
+		Password        *string `json:"password" binding:"omitempty,required"`
+		CurrentPassword string  `json:"current_password"`
+	}
+	deleteUserRequest struct {
+		Password string `json:"password" binding:"required"`
+	}
+	resetPasswordRequest struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=8,max=72"`
+	}
 )
 
 func (h *UserHandler) RegisterUser(ctx *gin.Context) {
@@ -56,10 +76,10 @@ func (h *UserHandler) RegisterUser(ctx *gin.Context) {
 		HandleError(ctx, err)
 		return
 	}
-	util.Background(h.wg, func() {
-		err = h.mailerService.Send(user.Email, "user_welcome.tmpl", user)
+	util.Background(ctx, h.wg, func(ctx context.Context) {
+		err = h.mailerService.Send(ctx, user.Email, "user_welcome.tmpl", user)
 		if err != nil {
-			logger.Error("failed to send welcome email", "msg", err)
+			logger.FromContext(ctx).Named(logger.Mailer).Error("failed to send welcome email", "msg", err)
 		}
 	})
 
@@ -67,3 +87,149 @@ func (h *UserHandler) RegisterUser(ctx *gin.Context) {
 		"user": user,
 	})
 }
+
+func (h *UserHandler) RequestEmailChange(ctx *gin.Context) {
+	var req changeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user := GetContextUser(ctx)
+	match, err := user.PasswordMatches(req.Password)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	if !match {
+		HandleError(ctx, domain.ErrInvalidCredentials)
+		return
+	}
+	token, err := h.userService.RequestEmailChange(ctx, user, req.Email)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	oldEmail := user.Email
+	util.Background(ctx, h.wg, func(ctx context.Context) {
+		data := map[string]any{
+			"userID":   user.ID,
+			"newEmail": req.Email,
+			"token":    token.Plaintext,
+		}
+		if err := h.mailerService.Send(ctx, req.Email, "user_email_change.tmpl", data); err != nil {
+			logger.FromContext(ctx).Named(logger.Mailer).Error("failed to send email change confirmation", "msg", err)
+		}
+		if err := h.mailerService.Send(ctx, oldEmail, "user_email_change_notice.tmpl", data); err != nil {
+			logger.FromContext(ctx).Named(logger.Mailer).Error("failed to send email change notice", "msg", err)
+		}
+	})
+
+	SendAcceptedSuccess(ctx, Envelope{
+		"user": user,
+	})
+}
+
+func (h *UserHandler) ConfirmEmailChange(ctx *gin.Context) {
+	var req confirmEmailChangeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user, err := h.userService.ConfirmEmailChange(ctx, req.Token)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendUpdatedSuccess(ctx, Envelope{
+		"user": user,
+	})
+}
+
+func (h *UserHandler) ShowCurrentUser(ctx *gin.Context) {
+	SendSuccess(ctx, Envelope{
+		"user": GetContextUser(ctx),
+	})
+}
+
+func (h *UserHandler) UpdateCurrentUser(ctx *gin.Context) {
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	if req.Name == nil && req.Password == nil {
+		HandleError(ctx, domain.ErrNoUpdatedData)
+		return
+	}
+
+	user := GetContextUser(ctx)
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Password != nil {
+		match, err := user.PasswordMatches(req.CurrentPassword)
+		if err != nil {
+			HandleError(ctx, err)
+			return
+		}
+		if !match {
+			HandleError(ctx, domain.ErrInvalidCredentials)
+			return
+		}
+		if err = user.Password.Set(*req.Password); err != nil {
+			HandleError(ctx, err)
+			return
+		}
+	}
+	if err := user.ValidateUser(); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+
+	if err := h.userService.UpdateUser(ctx, user); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendUpdatedSuccess(ctx, Envelope{
+		"user": user,
+	})
+}
+
+func (h *UserHandler) DeleteCurrentUser(ctx *gin.Context) {
+	var req deleteUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user := GetContextUser(ctx)
+	match, err := user.PasswordMatches(req.Password)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	if !match {
+		HandleError(ctx, domain.ErrInvalidCredentials)
+		return
+	}
+	if err = h.userService.DeleteUser(ctx, user.ID); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendDeletedSuccess(ctx)
+}
+
+func (h *UserHandler) ResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		HandleValidationError(ctx, err)
+		return
+	}
+	user, err := h.userService.ResetPassword(ctx, req.Token, req.Password)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SendUpdatedSuccess(ctx, Envelope{
+		"user": user,
+	})
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+	"github.com/thaian1234/green_light/pkg/logger"
+)
+
+// AccessLog writes one structured entry per request through the request's logger, so
+// it carries the request ID, route and user. It must run after RequestID and ClientIP.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		fields := []interface{}{
+			"path", c.Request.URL.Path,
+			"query", c.Request.URL.RawQuery,
+			"status", c.Writer.Status(),
+			"latency", time.Since(start),
+			"client_ip", handlers.GetContextClientIP(c),
+			"user_agent", c.Request.UserAgent(),
+			"bytes", c.Writer.Size(),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			fields = append(fields, "errors", errs)
+		}
+		logger.FromContext(c).Named(logger.HTTP).Info("request", fields...)
+	}
+}
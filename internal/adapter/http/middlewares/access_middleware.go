@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/adapter/access"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+// IPAccess rejects requests whose resolved client address is not allowed by the
+// rules of group. It must run after ClientIP.
+func IPAccess(rules *access.Rules, group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rules.Allow(group, handlers.GetContextClientIP(c)) {
+			handlers.HandleAbort(c, domain.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
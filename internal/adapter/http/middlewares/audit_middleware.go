@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+// AuditActor attaches who is making the request to the request context so services
+// can attribute audit events. It must run after Authenticate.
+func AuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := domain.AuditActor{
+			UserID:    handlers.GetContextUser(c).ID,
+			IP:        handlers.GetContextClientIP(c),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: handlers.GetContextRequestID(c),
+		}
+		c.Request = c.Request.WithContext(domain.WithAuditActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+)
+
+// Authenticate resolves the credential on the request to a user and stores it on the
+// context. An API key may be sent in the X-API-Key header or as a bearer token; any
+// other bearer token is treated as an authentication token. Requests without
+// credentials continue as the anonymous user; requests with a malformed or unknown
+// credential are rejected.
+func Authenticate(userSvc ports.UserService, apiKeySvc ports.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Authorization, X-API-Key")
+
+		credential := c.GetHeader("X-API-Key")
+		if authorizationHeader := c.GetHeader("Authorization"); credential == "" && authorizationHeader != "" {
+			headerParts := strings.Split(authorizationHeader, " ")
+			if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+				invalidAuthenticationToken(c)
+				return
+			}
+			credential = headerParts[1]
+		}
+		if credential == "" {
+			handlers.SetContextUser(c, domain.AnonymousUser)
+			c.Next()
+			return
+		}
+
+		if domain.IsAPIKey(credential) {
+			user, key, err := apiKeySvc.Authenticate(c, credential)
+			if err != nil {
+				if err == domain.ErrInvalidToken {
+					invalidAuthenticationToken(c)
+					return
+				}
+				handlers.HandleAbort(c, err)
+				return
+			}
+			handlers.SetContextUser(c, user)
+			handlers.SetContextAPIKey(c, key)
+			c.Next()
+			return
+		}
+
+		user, err := userSvc.GetUserForToken(c, domain.ScopeAuthentication, credential)
+		if err != nil {
+			if err == domain.ErrInvalidToken {
+				invalidAuthenticationToken(c)
+				return
+			}
+			handlers.HandleAbort(c, err)
+			return
+		}
+
+		handlers.SetContextUser(c, user)
+		c.Next()
+	}
+}
+
+// RequireAuthenticatedUser rejects requests made by the anonymous user.
+func RequireAuthenticatedUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handlers.GetContextUser(c).IsAnonymous() {
+			handlers.HandleAbort(c, domain.ErrUnauthorized)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission rejects requests from users that are not activated or do not
+// hold the given permission code. Requests made with an API key additionally need the
+// permission to be among the key's scopes.
+func RequirePermission(permissionSvc ports.PermissionService, code string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := handlers.GetContextUser(c)
+		if user.IsAnonymous() {
+			handlers.HandleAbort(c, domain.ErrUnauthorized)
+			return
+		}
+		if !user.IsActivated() {
+			handlers.HandleAbort(c, domain.ErrInactiveAccount)
+			return
+		}
+		permissions, err := permissionSvc.GetPermissionsForUser(c, user.ID)
+		if err != nil {
+			handlers.HandleAbort(c, err)
+			return
+		}
+		if !permissions.Include(code) {
+			handlers.HandleAbort(c, domain.ErrForbidden)
+			return
+		}
+		if key := handlers.GetContextAPIKey(c); key != nil && !key.Permissions.Include(code) {
+			handlers.HandleAbort(c, domain.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireKeyScope rejects requests authenticated with an API key that does not have
+// code among its scopes. Other requests are let through, so routes open to session
+// users and anonymous users stay that way.
+func RequireKeyScope(code string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := handlers.GetContextAPIKey(c); key != nil && !key.Permissions.Include(code) {
+			handlers.HandleAbort(c, domain.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSessionUser rejects requests authenticated with an API key, for actions that
+// must be performed by a person, such as managing API keys themselves.
+func RequireSessionUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handlers.GetContextAPIKey(c) != nil {
+			handlers.HandleAbort(c, domain.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+func invalidAuthenticationToken(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Bearer")
+	handlers.HandleAbort(c, domain.ErrInvalidToken)
+}
@@ -0,0 +1,114 @@
+package middlewares
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/adapter/access"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+)
+
+// Forwarding headers the ClientIP middleware can read the client address from.
+const (
+	ForwardedHeaderXFF       = "x-forwarded-for"
+	ForwardedHeaderForwarded = "forwarded"
+)
+
+// ParseTrustedProxies parses the CIDRs or bare addresses of proxies allowed to report
+// the client address through forwarding headers.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	return access.ParsePrefixes(proxies)
+}
+
+// ClientIP resolves the address of the client behind any trusted proxies and stores it
+// on the context for rate limiting, audit logging and access logs. Forwarding headers
+// are only honoured when the peer is a trusted proxy, and are read from the right so a
+// client cannot spoof its address by prepending entries. Only header, the one the
+// proxies write, is read: X-Forwarded-For or the RFC 7239 Forwarded header. A proxy
+// passes the other through untouched, so honouring it would let the client choose its
+// own address.
+func ClientIP(trusted []netip.Prefix, header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handlers.SetContextClientIP(c, resolveClientIP(c, trusted, header))
+		c.Next()
+	}
+}
+
+func resolveClientIP(c *gin.Context, trusted []netip.Prefix, header string) string {
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		host = c.Request.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	remote = remote.Unmap()
+	if !isTrustedProxy(remote, trusted) {
+		return remote.String()
+	}
+
+	var hops []string
+	if header == ForwardedHeaderForwarded {
+		hops = parseForwarded(c.Request.Header.Values("Forwarded"))
+	} else {
+		for _, value := range c.Request.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(value, ",")...)
+		}
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		client = addr
+		if !isTrustedProxy(addr, trusted) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// parseForwarded returns the for= parameter of every forwarded-element, in order.
+func parseForwarded(headers []string) []string {
+	var hops []string
+	for _, header := range headers {
+		for _, element := range strings.Split(header, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, value)
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseHop parses a single forwarding entry, accepting the quoted, bracketed and
+// port-suffixed forms allowed by RFC 7239. Obfuscated identifiers and "unknown" are
+// rejected.
+func parseHop(hop string) (netip.Addr, bool) {
+	hop = strings.Trim(strings.TrimSpace(hop), `"`)
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func isTrustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
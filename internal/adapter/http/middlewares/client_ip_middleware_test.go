@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		header  string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "untrusted peer",
+			header:  ForwardedHeaderXFF,
+			remote:  "192.0.2.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:    "192.0.2.1",
+		},
+		{
+			name:    "x-forwarded-for read from the right",
+			header:  ForwardedHeaderXFF,
+			remote:  "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.1, 10.0.0.2"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:   "forwarded ignored behind an x-forwarded-for proxy",
+			header: ForwardedHeaderXFF,
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded":       "for=203.0.113.9",
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			want: "198.51.100.1",
+		},
+		{
+			name:   "x-forwarded-for ignored behind a forwarded proxy",
+			header: ForwardedHeaderForwarded,
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8::1]:4711"`,
+				"X-Forwarded-For": "203.0.113.9",
+			},
+			want: "2001:db8::1",
+		},
+		{
+			name:    "missing header",
+			header:  ForwardedHeaderForwarded,
+			remote:  "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.9"},
+			want:    "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			router := gin.New()
+			router.Use(ClientIP(trusted, tt.header))
+			router.GET("/", func(c *gin.Context) {
+				got = handlers.GetContextClientIP(c)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = tt.remote
+			for name, value := range tt.headers {
+				request.Header.Set(name, value)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), request)
+			if got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
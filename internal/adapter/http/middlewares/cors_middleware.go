@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/pkg/logger"
+)
+
+// CORS applies the CORS policy for the configured allowed origins, rebuilding it when
+// they change on a config reload. Origins the policy would reject, such as a
+// chrome-extension:// origin, fail at startup and are ignored on reload, keeping the
+// current policy.
+func CORS(live *config.Live) (gin.HandlerFunc, error) {
+	var handler atomic.Pointer[gin.HandlerFunc]
+	build := func(cfg *config.Config) error {
+		corsConfig := cors.DefaultConfig()
+		corsConfig.AllowOrigins = cfg.HTTP.AllowedOrigins
+		// cors.New panics on a config it considers invalid.
+		if err := corsConfig.Validate(); err != nil {
+			return err
+		}
+		h := cors.New(corsConfig)
+		handler.Store(&h)
+		return nil
+	}
+	if err := build(live.Get()); err != nil {
+		return nil, err
+	}
+	live.OnChange(func(cfg *config.Config, changes []config.Change) {
+		if !config.Applied(changes, "http.allowed_origins") {
+			return
+		}
+		if err := build(cfg); err != nil {
+			logger.Error("kept the current CORS policy, reloaded allowed origins are invalid", "err", err)
+		}
+	})
+
+	return func(c *gin.Context) {
+		(*handler.Load())(c)
+	}, nil
+}
@@ -0,0 +1,19 @@
+package middlewares
+
+import (
+	"testing"
+
+	"github.com/thaian1234/green_light/config"
+)
+
+func TestCORSRejectsInvalidOrigins(t *testing.T) {
+	live := config.NewLive(&config.Config{HTTP: &config.HTTP{AllowedOrigins: []string{"chrome-extension://abc"}}})
+	if _, err := CORS(live); err == nil {
+		t.Error("CORS accepted a chrome-extension origin")
+	}
+
+	live = config.NewLive(&config.Config{HTTP: &config.HTTP{AllowedOrigins: []string{"https://example.com"}}})
+	if _, err := CORS(live); err != nil {
+		t.Errorf("CORS rejected an https origin: %v", err)
+	}
+}
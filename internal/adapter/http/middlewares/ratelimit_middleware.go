@@ -1,69 +1,124 @@
 package middlewares
 
 import (
-	"net"
-	"net/http"
-	"sync"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thaian1234/green_light/config"
-	"golang.org/x/time/rate"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+	"github.com/thaian1234/green_light/internal/adapter/ratelimit"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/metrics"
 )
 
-func RateLimit(cfg *config.Limiter) gin.HandlerFunc {
-	type client struct {
-		limiter  *rate.Limiter
-		lastSeen time.Time
+// DefaultRateLimitPolicy is the policy applied to every route.
+func DefaultRateLimitPolicy(cfg *config.Limiter) ratelimit.Policy {
+	return ratelimit.Policy{
+		Name:  "default",
+		Rps:   cfg.Rps,
+		Burst: cfg.Burst,
 	}
+}
 
-	var (
-		mu      sync.Mutex
-		clients = make(map[string]*client)
-	)
+// AuthRateLimitPolicy is the stricter policy for registration and login routes. It
+// falls back to one request per second with a burst of five when not configured.
+func AuthRateLimitPolicy(cfg *config.Limiter) ratelimit.Policy {
+	policy := ratelimit.Policy{
+		Name:  "auth",
+		Rps:   cfg.AuthRps,
+		Burst: cfg.AuthBurst,
+	}
+	if policy.Rps <= 0 {
+		policy.Rps = 1
+	}
+	if policy.Burst <= 0 {
+		policy.Burst = 5
+	}
+	return policy
+}
 
-	// Cleanup old entries.
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-			mu.Lock()
-			for ip, client := range clients {
-				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
-				}
-			}
-			mu.Unlock()
-		}
-	}()
+// IPRateLimitPolicy is the per IP ceiling checked before credentials are looked up. It
+// falls back to twenty requests per second with a burst of forty when not configured.
+func IPRateLimitPolicy(cfg *config.Limiter) ratelimit.Policy {
+	policy := ratelimit.Policy{
+		Name:  "ip",
+		Rps:   cfg.IPRps,
+		Burst: cfg.IPBurst,
+	}
+	if policy.Rps <= 0 {
+		policy.Rps = 20
+	}
+	if policy.Burst <= 0 {
+		policy.Burst = 40
+	}
+	return policy
+}
 
+// RateLimit enforces the policy built from the current limiter config per client using
+// limiter, so reloaded limits apply to the next request. Clients are identified by
+// their API key or user when the request is authenticated, and by IP address
+// otherwise, so it must run after Authenticate. Every response carries
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers; rejected requests
+// also get Retry-After. When the limiter itself fails, FailOpen decides whether the
+// request goes through.
+func RateLimit(live *config.Live, limiter ratelimit.Limiter, policyFor func(*config.Limiter) ratelimit.Policy) gin.HandlerFunc {
+	return rateLimit(live, limiter, policyFor, rateLimitKey)
+}
+
+// RateLimitByIP is RateLimit keyed by client IP address only. It runs ahead of
+// Authenticate, so floods of made-up credentials are rejected before each one costs a
+// database lookup.
+func RateLimitByIP(live *config.Live, limiter ratelimit.Limiter, policyFor func(*config.Limiter) ratelimit.Policy) gin.HandlerFunc {
+	return rateLimit(live, limiter, policyFor, func(c *gin.Context) string {
+		return "ip:" + handlers.GetContextClientIP(c)
+	})
+}
+
+func rateLimit(live *config.Live, limiter ratelimit.Limiter, policyFor func(*config.Limiter) ratelimit.Policy, keyFor func(*gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if cfg.Enabled {
-			ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
-			if err != nil {
-				c.AbortWithStatus(http.StatusInternalServerError)
+		cfg := live.Get().Limiter
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+		policy := policyFor(cfg)
+		result, err := limiter.Allow(c, keyFor(c), policy)
+		if err != nil {
+			logger.FromContext(c).Error("rate limiter unavailable", "policy", policy.Name, "fail_open", cfg.FailOpen, "err", err)
+			if cfg.FailOpen {
+				c.Next()
 				return
 			}
-			// Lock the mutex to prevent this code from being executed concurrently.
-			mu.Lock()
-			if _, found := clients[ip]; !found {
-				clients[ip] = &client{
-					limiter: rate.NewLimiter(rate.Limit(cfg.Rps), cfg.Burst),
-				}
-			}
-			clients[ip].lastSeen = time.Now()
+			handlers.HandleAbort(c, domain.ErrServiceUnavailable)
+			return
+		}
 
-			if !clients[ip].limiter.Allow() {
-				mu.Unlock()
-				c.AbortWithStatus(http.StatusTooManyRequests)
-				return
-			}
-			// Very importantly, unlock the mutex before calling the next handler in thes
-			// chain. Notice that we DON'T use defer to unlock the mutex, as that would mean
-			// that the mutex isn't unlocked until all the handlers downstream of this
-			// middleware have also returned.
-			mu.Unlock()
-			c.Next()
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			metrics.RateLimitRejections.WithLabelValues(policy.Name).Inc()
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			handlers.HandleAbort(c, domain.ErrRateLimitExceeded)
+			return
 		}
+		c.Next()
+	}
+}
 
+func rateLimitKey(c *gin.Context) string {
+	if key := handlers.GetContextAPIKey(c); key != nil {
+		return "api_key:" + strconv.FormatInt(key.ID, 10)
 	}
+	if user := handlers.GetContextUser(c); !user.IsAnonymous() {
+		return "user:" + strconv.FormatInt(user.ID, 10)
+	}
+	return "ip:" + handlers.GetContextClientIP(c)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/adapter/ratelimit"
+	"github.com/thaian1234/green_light/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := logger.Initialize(&config.Logger{LogLevel: "fatal", Outputs: []string{"stdout"}}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newRateLimitedRouter(t *testing.T, limiterCfg *config.Limiter) (*gin.Engine, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	server.SetTime(time.Unix(1_700_000_000, 0))
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	live := config.NewLive(&config.Config{Limiter: limiterCfg})
+	router := gin.New()
+	router.Use(RateLimit(live, ratelimit.NewRedisLimiter(client), DefaultRateLimitPolicy))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router, server
+}
+
+func serve(router *gin.Engine) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "192.0.2.1:1234"
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRateLimitRedis(t *testing.T) {
+	router, _ := newRateLimitedRouter(t, &config.Limiter{Enabled: true, Rps: 1, Burst: 2})
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		recorder := serve(router)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+		}
+		if got := recorder.Header().Get("RateLimit-Remaining"); got != strconv.Itoa(remaining) {
+			t.Errorf("RateLimit-Remaining = %q, want %d", got, remaining)
+		}
+	}
+
+	recorder := serve(router)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+	if got := recorder.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, want %q", got, "2")
+	}
+}
+
+func TestRateLimitRedisUnavailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		failOpen bool
+		want     int
+	}{
+		{name: "fail open", failOpen: true, want: http.StatusOK},
+		{name: "fail closed", failOpen: false, want: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, server := newRateLimitedRouter(t, &config.Limiter{Enabled: true, Rps: 1, Burst: 1, FailOpen: tt.failOpen})
+			server.Close()
+
+			recorder := serve(router)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+			if got := recorder.Header().Get("RateLimit-Limit"); got != "" {
+				t.Errorf("RateLimit-Limit = %q, want no header", got)
+			}
+		})
+	}
+}
+
+func TestRateLimitByIPRunsBeforeAuthentication(t *testing.T) {
+	live := config.NewLive(&config.Config{Limiter: &config.Limiter{Enabled: true, IPRps: 1, IPBurst: 1}})
+	lookups := 0
+	router := gin.New()
+	router.Use(RateLimitByIP(live, ratelimit.NewMemoryLimiter(), IPRateLimitPolicy))
+	router.Use(func(c *gin.Context) {
+		lookups++
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	if recorder := serve(router); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+	if recorder := serve(router); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if lookups != 1 {
+		t.Errorf("credentials were looked up %d times, want 1", lookups)
+	}
+}
@@ -0,0 +1,15 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+// ReplicaReads lets the database reads of a route be served by a read replica. Use it
+// only on routes that never write, as replicas may lag behind the primary.
+func ReplicaReads() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(domain.WithReplicaReads(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// RequestID assigns every request an ID, reusing a well-formed X-Request-ID from the
+// caller, echoes it in the response and attaches a logger carrying it to the request
+// context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		handlers.SetContextRequestID(c, requestID)
+		c.Header(requestIDHeader, requestID)
+
+		fields := []interface{}{
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+		}
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.HasTraceID() {
+			fields = append(fields, "trace_id", span.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(logger.With(c.Request.Context(), fields...))
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so they are safe to
+// log and echo back.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
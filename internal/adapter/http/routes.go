@@ -2,12 +2,16 @@ package http
 
 import (
 	"net/http"
-	"strings"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/adapter/access"
 	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
+	"github.com/thaian1234/green_light/internal/adapter/http/middlewares"
+	"github.com/thaian1234/green_light/internal/adapter/ratelimit"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
 )
 
 type Routes struct {
@@ -17,21 +21,31 @@ type Routes struct {
 
 func NewRoutes(
 	r *gin.Engine,
-	cfg *config.Config,
+	live *config.Live,
+	limiter ratelimit.Limiter,
+	accessRules *access.Rules,
 	healthHandler *handlers.HealthHandler,
 	movieHandler *handlers.MovieHandler,
 	userHandler *handlers.UserHandler,
+	tokenHandler *handlers.TokenHandler,
+	twoFactorHandler *handlers.TwoFactorHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	oidcHandler *handlers.OIDCHandler,
+	auditHandler *handlers.AuditHandler,
+	adminHandler *handlers.AdminHandler,
+	logLevelHandler *handlers.LogLevelHandler,
+	permissionSvc ports.PermissionService,
 ) (*Routes, error) {
+	cfg := live.Get()
 	if cfg.App.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	ginConfig := cors.DefaultConfig()
-	allowedOrigins := cfg.HTTP.AllowedOrigins
-	originsList := strings.Split(allowedOrigins, ",")
-	ginConfig.AllowOrigins = originsList
-
-	r.Use(cors.New(ginConfig))
+	cors, err := middlewares.CORS(live)
+	if err != nil {
+		return nil, err
+	}
+	r.Use(cors)
 	r.NoRoute(gin.HandlerFunc(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "API Not found"})
 	}))
@@ -39,6 +53,13 @@ func NewRoutes(
 		c.JSON(http.StatusMethodNotAllowed, gin.H{"message": "Method not allowed"})
 	}))
 
+	r.GET("/metrics", middlewares.IPAccess(accessRules, access.GroupMetrics), gin.WrapH(promhttp.Handler()))
+
+	authRateLimit := middlewares.RateLimit(live, limiter, middlewares.AuthRateLimitPolicy)
+	replicaReads := middlewares.ReplicaReads()
+	moviesRead := middlewares.RequireKeyScope(domain.PermissionMoviesRead)
+	moviesWrite := middlewares.RequireKeyScope(domain.PermissionMoviesWrite)
+
 	v1 := r.Group("/v1/api")
 	{
 		// Health route
@@ -49,16 +70,71 @@ func NewRoutes(
 		// Movie route
 		movie := v1.Group("/movies")
 		{
-			movie.GET("/:id", movieHandler.ShowMovie)
-			movie.GET("/", movieHandler.ListMovies)
-			movie.POST("/", movieHandler.CreateMovie)
-			movie.PATCH("/:id", movieHandler.UpdateMovie)
-			movie.DELETE("/:id", movieHandler.DeleteMovie)
+			movie.GET("/:id", moviesRead, replicaReads, movieHandler.ShowMovie)
+			movie.GET("/", moviesRead, replicaReads, movieHandler.ListMovies)
+			movie.POST("/", moviesWrite, movieHandler.CreateMovie)
+			movie.PATCH("/:id", moviesWrite, movieHandler.UpdateMovie)
+			movie.DELETE("/:id", moviesWrite, movieHandler.DeleteMovie)
 		}
 		// User route
 		user := v1.Group("/users")
 		{
-			user.POST("/", userHandler.RegisterUser)
+			user.POST("/", authRateLimit, userHandler.RegisterUser)
+			user.PUT("/email/confirm", userHandler.ConfirmEmailChange)
+			user.PUT("/password", userHandler.ResetPassword)
+
+			// Account settings are managed by the person, never through an API key
+			me := user.Group("/me", middlewares.RequireAuthenticatedUser(), middlewares.RequireSessionUser())
+			{
+				me.GET("/", userHandler.ShowCurrentUser)
+				me.PATCH("/", userHandler.UpdateCurrentUser)
+				me.DELETE("/", userHandler.DeleteCurrentUser)
+				me.PATCH("/email", userHandler.RequestEmailChange)
+				me.POST("/2fa", twoFactorHandler.BeginEnrolment)
+				me.POST("/2fa/confirm", twoFactorHandler.ConfirmEnrolment)
+				me.DELETE("/2fa", twoFactorHandler.Disable)
+
+				apiKey := me.Group("/api-keys")
+				{
+					apiKey.POST("/", apiKeyHandler.CreateAPIKey)
+					apiKey.GET("/", apiKeyHandler.ListAPIKeys)
+					apiKey.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+				}
+			}
+		}
+		// Token route
+		token := v1.Group("/tokens")
+		{
+			token.POST("/authentication", authRateLimit, tokenHandler.CreateAuthenticationToken)
+			token.POST("/authentication/2fa", authRateLimit, tokenHandler.CompleteTwoFactorLogin)
+		}
+		// SSO route
+		if cfg.OIDC.Enabled {
+			oidc := v1.Group("/auth/oidc")
+			{
+				oidc.GET("/login", oidcHandler.Login)
+				oidc.GET("/callback", oidcHandler.Callback)
+			}
+		}
+		// Admin route
+		admin := v1.Group("/admin",
+			middlewares.IPAccess(accessRules, access.GroupAdmin),
+			middlewares.RequirePermission(permissionSvc, domain.PermissionAdmin),
+		)
+		{
+			adminUser := admin.Group("/users")
+			{
+				adminUser.GET("/", replicaReads, adminHandler.ListUsers)
+				adminUser.GET("/:id", replicaReads, adminHandler.ShowUser)
+				adminUser.POST("/:id/activate", adminHandler.ActivateUser)
+				adminUser.POST("/:id/deactivate", adminHandler.DeactivateUser)
+				adminUser.POST("/:id/permissions", adminHandler.GrantPermissions)
+				adminUser.DELETE("/:id/permissions", adminHandler.RevokePermissions)
+				adminUser.POST("/:id/password-reset", adminHandler.SendPasswordReset)
+			}
+			admin.GET("/audit-events", auditHandler.ListEvents)
+			admin.GET("/log-level", logLevelHandler.ShowLevels)
+			admin.PUT("/log-level", logLevelHandler.SetLevel)
 		}
 	}
 
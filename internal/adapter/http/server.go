@@ -8,55 +8,134 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/adapter/access"
 	"github.com/thaian1234/green_light/internal/adapter/http/handlers"
 	"github.com/thaian1234/green_light/internal/adapter/http/middlewares"
+	"github.com/thaian1234/green_light/internal/adapter/oidc"
+	"github.com/thaian1234/green_light/internal/adapter/ratelimit"
 	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
 	"github.com/thaian1234/green_light/internal/adapter/storages/postgres/repository"
+	"github.com/thaian1234/green_light/internal/adapter/storages/redis"
 	"github.com/thaian1234/green_light/internal/core/services"
 	"github.com/thaian1234/green_light/pkg/logger"
 	"github.com/thaian1234/green_light/pkg/util"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+// loginFailurePruneInterval is how often failed logins outside the counting window are
+// deleted.
+const loginFailurePruneInterval = time.Minute
+
 type Adapter struct {
-	cfg *config.Config
-	srv *http.Server
-	db  *postgres.Adapter
-	wg  *sync.WaitGroup
+	cfg  *config.Config
+	srv  *http.Server
+	db   *postgres.Adapter
+	wg   *sync.WaitGroup
+	stop chan struct{}
 }
 
-func NewAdapter(cfg *config.Config, db *postgres.Adapter, wg *sync.WaitGroup) *Adapter {
-	router := gin.Default()
+func NewAdapter(live *config.Live, db *postgres.Adapter, rdb *redis.Adapter, wg *sync.WaitGroup) *Adapter {
+	cfg := live.Get()
+	router := gin.New()
+	// Let gin.Context fall back to the request context so values attached by
+	// middlewares are visible to services.
+	router.ContextWithFallback = true
+	// Client addresses are resolved by the ClientIP middleware; gin itself must not
+	// trust forwarding headers from arbitrary peers.
+	if err := router.SetTrustedProxies(nil); err != nil {
+		logger.Fatal("failed to configure trusted proxies ", err)
+	}
+	trustedProxies, err := middlewares.ParseTrustedProxies(cfg.HTTP.TrustedProxies)
+	if err != nil {
+		logger.Fatal("failed to parse trusted proxies ", err)
+	}
+	router.Use(otelgin.Middleware(cfg.App.Name))
+	router.Use(middlewares.RequestID())
+	router.Use(middlewares.ClientIP(trustedProxies, cfg.HTTP.ForwardedHeader))
+	router.Use(middlewares.AccessLog(), middlewares.Metrics(), gin.Recovery())
+	prometheus.MustRegister(db.NewPoolCollector())
 
-	// Middlewares
-	router.Use(middlewares.RateLimit(cfg.Limiter))
+	accessRules, err := access.NewRules(cfg.Access)
+	if err != nil {
+		logger.Fatal("failed to load access rules ", err)
+	}
+	router.Use(middlewares.IPAccess(accessRules, access.GroupDefault))
 
 	// Custom Validator
 	validator := util.NewValidator()
 	validator.SetupValidator()
 
 	// repositories
-	movieRepo := repository.NewMovieRepository(db.Pool)
-	userRepo := repository.NewUserRepository(db.Pool)
+	movieRepo := repository.NewMovieRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	permissionRepo := repository.NewPermissionRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
 
 	// services
-	healthSvc := services.NewHealthService(cfg)
-	movieSvc := services.NewMovieService(movieRepo)
-	userSvc := services.NewUserService(userRepo)
+	txManager := postgres.NewTxManager(db)
+	auditSvc := services.NewAuditService(auditRepo, txManager)
+	healthSvc := services.NewHealthService(cfg, db)
+	movieSvc := services.NewMovieService(movieRepo, auditSvc)
+	loginAttemptSvc := services.NewLoginAttemptService(cfg.Login, loginAttemptRepo, userRepo, auditSvc)
+	twoFactorSvc := services.NewTwoFactorService(cfg.App.Name, twoFactorRepo, auditSvc)
+	tokenSvc, err := services.NewTokenService(cfg.Token, tokenRepo, userRepo, loginAttemptSvc, twoFactorSvc, auditSvc)
+	if err != nil {
+		logger.Fatal("failed to setup token service ", err)
+	}
+	userSvc := services.NewUserService(userRepo, tokenSvc, auditSvc, txManager)
 	mailerSvc := services.NewMailerService(cfg.Smtp)
+	permissionSvc := services.NewPermissionService(permissionRepo, auditSvc)
+	apiKeySvc := services.NewAPIKeyService(apiKeyRepo, userRepo, permissionRepo, auditSvc)
+	oidcSvc := services.NewOIDCService(oidc.NewAdapter(cfg.OIDC), identityRepo, userRepo, tokenSvc, auditSvc)
+
+	// Rate limiter
+	var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+	if cfg.Limiter.Store == ratelimit.StoreRedis {
+		limiter = ratelimit.NewRedisLimiter(rdb.Client)
+	}
+
+	// Middlewares
+	router.Use(middlewares.RateLimitByIP(live, limiter, middlewares.IPRateLimitPolicy))
+	router.Use(middlewares.Authenticate(userSvc, apiKeySvc))
+	router.Use(middlewares.RateLimit(live, limiter, middlewares.DefaultRateLimitPolicy))
+	router.Use(middlewares.AuditActor())
 
 	// Handlers
 	healthHandler := handlers.NewHealthHandler(healthSvc)
 	movieHandler := handlers.NewMovieHandler(movieSvc)
 	userHandler := handlers.NewUserHandler(wg, userSvc, mailerSvc)
+	tokenHandler := handlers.NewTokenHandler(wg, tokenSvc, mailerSvc)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorSvc)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeySvc)
+	oidcHandler := handlers.NewOIDCHandler(oidcSvc)
+	auditHandler := handlers.NewAuditHandler(auditSvc)
+	adminHandler := handlers.NewAdminHandler(wg, userSvc, permissionSvc, mailerSvc)
+	logLevelHandler := handlers.NewLogLevelHandler()
 
 	// Routes
-	_, err := NewRoutes(
+	_, err = NewRoutes(
 		router,
-		cfg,
+		live,
+		limiter,
+		accessRules,
 		healthHandler,
 		movieHandler,
 		userHandler,
+		tokenHandler,
+		twoFactorHandler,
+		apiKeyHandler,
+		oidcHandler,
+		auditHandler,
+		adminHandler,
+		logLevelHandler,
+		permissionSvc,
 	)
 
 	if err != nil {
@@ -64,23 +143,49 @@ func NewAdapter(cfg *config.Config, db *postgres.Adapter, wg *sync.WaitGroup) *A
 	}
 
 	srv := &http.Server{
-		Addr:           fmt.Sprintf(":%s", cfg.HTTP.Port),
+		Addr:           fmt.Sprintf(":%d", cfg.HTTP.Port),
 		Handler:        router,
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 		MaxHeaderBytes: 1 << 20,
 	}
 
-	return &Adapter{
-		cfg: cfg,
-		srv: srv,
-		db:  db,
-		wg:  wg,
+	adapter := &Adapter{
+		cfg:  cfg,
+		srv:  srv,
+		db:   db,
+		wg:   wg,
+		stop: make(chan struct{}),
 	}
+	adapter.every(loginFailurePruneInterval, func(ctx context.Context) {
+		if _, err := loginAttemptSvc.PruneFailures(ctx); err != nil {
+			logger.Error("failed to prune login failures", "err", err)
+		}
+	})
+	return adapter
+}
+
+// every runs job at each interval until the adapter is stopped. The job is tracked by
+// wg, so shutdown waits for a run in progress to finish.
+func (a *Adapter) every(interval time.Duration, job func(ctx context.Context)) {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				job(context.Background())
+			case <-a.stop:
+				return
+			}
+		}
+	}()
 }
 
 func (a *Adapter) Run() error {
-	logger.Info("Server is running on port::" + a.cfg.HTTP.Port)
+	logger.Info(fmt.Sprintf("Server is running on port::%d", a.cfg.HTTP.Port))
 	if err := a.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		logger.Error("Failed to run server", err)
 		return err
@@ -89,5 +194,6 @@ func (a *Adapter) Run() error {
 }
 
 func (a *Adapter) Stop(ctx context.Context) error {
+	close(a.stop)
 	return a.srv.Shutdown(ctx)
 }
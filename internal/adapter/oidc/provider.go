@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"golang.org/x/oauth2"
+)
+
+// Adapter talks to an OpenID Connect issuer using the authorization code flow with
+// PKCE. The discovery document and JWKS are fetched lazily on first use, so the
+// server can start while the issuer is unreachable.
+type Adapter struct {
+	cfg *config.OIDC
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+func NewAdapter(cfg *config.OIDC) *Adapter {
+	return &Adapter{
+		cfg: cfg,
+	}
+}
+
+func (a *Adapter) AuthCodeURL(ctx context.Context, state *domain.OIDCLoginState) (string, error) {
+	oauth2Config, _, err := a.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauth2Config.AuthCodeURL(
+		state.State,
+		gooidc.Nonce(state.Nonce),
+		oauth2.S256ChallengeOption(state.CodeVerifier),
+	), nil
+}
+
+// Exchange redeems the authorization code and verifies the returned ID token: its
+// signature against the issuer's JWKS, the issuer, audience, expiry and nonce.
+func (a *Adapter) Exchange(ctx context.Context, code string, state *domain.OIDCLoginState) (*domain.ExternalIdentity, error) {
+	oauth2Config, verifier, err := a.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		logger.FromContext(ctx).Warn("oidc code exchange failed", "err", err)
+		return nil, domain.ErrIdentityProvider
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		logger.FromContext(ctx).Warn("oidc token response has no id_token")
+		return nil, domain.ErrIdentityProvider
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		logger.FromContext(ctx).Warn("oidc id token verification failed", "err", err)
+		return nil, domain.ErrInvalidToken
+	}
+	if idToken.Nonce != state.Nonce {
+		return nil, domain.ErrInvalidToken
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	return &domain.ExternalIdentity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (a *Adapter) discover(ctx context.Context) (*oauth2.Config, *gooidc.IDTokenVerifier, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.oauth2 != nil {
+		return a.oauth2, a.verifier, nil
+	}
+
+	discoveryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	provider, err := gooidc.NewProvider(discoveryCtx, a.cfg.Issuer)
+	if err != nil {
+		logger.FromContext(ctx).Error("oidc discovery failed", "issuer", a.cfg.Issuer, "err", err)
+		return nil, nil, domain.ErrIdentityProvider
+	}
+
+	scopes := []string{gooidc.ScopeOpenID, "email", "profile"}
+	if len(a.cfg.Scopes) > 0 {
+		scopes = append([]string{gooidc.ScopeOpenID}, a.cfg.Scopes...)
+	}
+	a.oauth2 = &oauth2.Config{
+		ClientID:     a.cfg.ClientID,
+		ClientSecret: a.cfg.ClientSecret,
+		RedirectURL:  a.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	a.verifier = provider.Verifier(&gooidc.Config{ClientID: a.cfg.ClientID})
+	return a.oauth2, a.verifier, nil
+}
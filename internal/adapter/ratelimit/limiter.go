@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// Policy is a bucket of Burst requests refilled at Rps per second, applied separately
+// to every client key.
+type Policy struct {
+	Name  string
+	Rps   int
+	Burst int
+}
+
+// Result describes the outcome of a single Allow call.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of requests the client could still make right now.
+	Remaining int
+	// RetryAfter is how long a rejected client has to wait for its next request.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the client's bucket is full again.
+	ResetAfter time.Duration
+}
+
+// Limiter decides whether the client identified by key may make one more request
+// under policy.
+type Limiter interface {
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// MemoryLimiter keeps a token bucket per client in process memory. Every replica
+// enforces its own limit, so it is only suitable for a single instance.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*client
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	l := &MemoryLimiter{
+		clients: make(map[string]*client),
+	}
+
+	// Cleanup old entries.
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+			l.mu.Lock()
+			for key, client := range l.clients {
+				if time.Since(client.lastSeen) > 3*time.Minute {
+					delete(l.clients, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}()
+
+	return l
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	key = policy.Name + ":" + key
+	limit := rate.Limit(policy.Rps)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, found := l.clients[key]
+	if !found {
+		c = &client{
+			limiter: rate.NewLimiter(limit, policy.Burst),
+		}
+		l.clients[key] = c
+	}
+	// Pick up policy changes for clients that already have a bucket.
+	if c.limiter.Limit() != limit {
+		c.limiter.SetLimit(limit)
+	}
+	if c.limiter.Burst() != policy.Burst {
+		c.limiter.SetBurst(policy.Burst)
+	}
+
+	now := time.Now()
+	c.lastSeen = now
+	allowed := c.limiter.AllowN(now, 1)
+	tokens := c.limiter.TokensAt(now)
+
+	result := Result{
+		Allowed:    allowed,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: refillTime(float64(policy.Burst)-tokens, policy.Rps),
+	}
+	if !allowed {
+		result.RetryAfter = refillTime(1-tokens, policy.Rps)
+	}
+	return result, nil
+}
+
+// refillTime returns how long it takes to refill the given number of tokens at rps.
+func refillTime(tokens float64, rps int) time.Duration {
+	if tokens <= 0 || rps <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(rps) * float64(time.Second))
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "ratelimit:"
+
+// gcraScript implements the generic cell rate algorithm. The only state per client is
+// its theoretical arrival time (TAT), read and written in one script so concurrent
+// replicas cannot race. Time comes from the Redis server so replica clocks do not
+// matter.
+//
+// KEYS[1] client key
+// ARGV[1] burst, ARGV[2] requests per second
+//
+// Returns {allowed, remaining, retry_after, reset_after}, durations in seconds as
+// strings to keep their fractional part.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+
+local emission_interval = 1 / rate
+local burst_offset = emission_interval * burst
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local tat = tonumber(redis.call("GET", key))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission_interval
+local allow_at = new_tat - burst_offset
+local diff = now - allow_at
+
+if diff < 0 then
+	return {0, 0, tostring(-diff), tostring(tat - now)}
+end
+
+local reset_after = new_tat - now
+redis.call("SET", key, tostring(new_tat), "PX", math.ceil(reset_after * 1000))
+return {1, math.floor(diff / emission_interval), "0", tostring(reset_after)}
+`)
+
+// RedisLimiter enforces limits shared by every replica using a GCRA script in Redis.
+type RedisLimiter struct {
+	client redis.Scripter
+}
+
+func NewRedisLimiter(client redis.Scripter) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+	}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	if policy.Rps <= 0 || policy.Burst <= 0 {
+		return Result{Allowed: false}, nil
+	}
+	redisKey := redisKeyPrefix + policy.Name + ":" + key
+	values, err := gcraScript.Run(ctx, l.client, []string{redisKey}, policy.Burst, policy.Rps).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	retryAfter, err := parseSeconds(values[2])
+	if err != nil {
+		return Result{}, err
+	}
+	resetAfter, err := parseSeconds(values[3])
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    values[0].(int64) == 1,
+		Remaining:  int(values[1].(int64)),
+		RetryAfter: retryAfter,
+		ResetAfter: resetAfter,
+	}, nil
+}
+
+func parseSeconds(value any) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(value.(string), 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisLimiter(t *testing.T) (*RedisLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	server.SetTime(time.Unix(1_700_000_000, 0))
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisLimiter(client), server
+}
+
+func TestRedisLimiterAllowsBurst(t *testing.T) {
+	limiter, server := newTestRedisLimiter(t)
+	policy := Policy{Name: "test", Rps: 2, Burst: 3}
+
+	for want := 2; want >= 0; want-- {
+		result, err := limiter.Allow(context.Background(), "client", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != want || result.RetryAfter != 0 {
+			t.Fatalf("result = %+v, want allowed with %d remaining", result, want)
+		}
+	}
+	if ttl := server.TTL(redisKeyPrefix + "test:client"); ttl <= 0 || ttl > 1500*time.Millisecond {
+		t.Errorf("key TTL = %v, want the time until the bucket is full", ttl)
+	}
+}
+
+func TestRedisLimiterDeniesWithRetryAfter(t *testing.T) {
+	limiter, server := newTestRedisLimiter(t)
+	policy := Policy{Name: "test", Rps: 2, Burst: 2}
+	now := time.Unix(1_700_000_000, 0)
+
+	for range 2 {
+		if result, err := limiter.Allow(context.Background(), "client", policy); err != nil || !result.Allowed {
+			t.Fatalf("result = %+v, %v; want allowed", result, err)
+		}
+	}
+
+	result, err := limiter.Allow(context.Background(), "client", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.Remaining != 0 {
+		t.Fatalf("result = %+v, want denied", result)
+	}
+	if result.RetryAfter != 500*time.Millisecond || result.ResetAfter != time.Second {
+		t.Errorf("retry after %v, reset after %v; want 500ms and 1s", result.RetryAfter, result.ResetAfter)
+	}
+
+	// Other clients have their own bucket.
+	if result, err = limiter.Allow(context.Background(), "other", policy); err != nil || !result.Allowed {
+		t.Errorf("other client: result = %+v, %v; want allowed", result, err)
+	}
+
+	// A denied request does not push the retry time further out.
+	server.SetTime(now.Add(250 * time.Millisecond))
+	if result, err = limiter.Allow(context.Background(), "client", policy); err != nil || result.Allowed || result.RetryAfter != 250*time.Millisecond {
+		t.Errorf("result = %+v, %v; want denied for another 250ms", result, err)
+	}
+
+	server.SetTime(now.Add(500 * time.Millisecond))
+	if result, err = limiter.Allow(context.Background(), "client", policy); err != nil || !result.Allowed || result.Remaining != 0 {
+		t.Errorf("result = %+v, %v; want allowed once a token is refilled", result, err)
+	}
+}
+
+func TestRedisLimiterRejectsEmptyPolicy(t *testing.T) {
+	limiter, _ := newTestRedisLimiter(t)
+
+	result, err := limiter.Allow(context.Background(), "client", Policy{Name: "test"})
+	if err != nil || result.Allowed {
+		t.Errorf("result = %+v, %v; want denied", result, err)
+	}
+}
+
+func TestRedisLimiterUnavailable(t *testing.T) {
+	limiter, server := newTestRedisLimiter(t)
+	server.Close()
+
+	if _, err := limiter.Allow(context.Background(), "client", Policy{Name: "test", Rps: 1, Burst: 1}); err == nil {
+		t.Error("Allow succeeded with Redis down")
+	}
+}
@@ -3,19 +3,28 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/core/domain"
 )
 
+// Adapter is the connection pool shared by the repositories, along with the read
+// replicas that Reader may route to. QueryTimeout bounds every query run through Conn
+// and Reader.
 type Adapter struct {
 	*pgxpool.Pool
+	QueryTimeout time.Duration
+
+	replicas *replicaSet
 }
 
 func NewAdapter(ctx context.Context, cfg *config.DB) (*Adapter, error) {
-	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name)
-	dbConfig, err := pgxpool.ParseConfig(dsn)
+	dbConfig, err := pgxpool.ParseConfig(connURL("postgres", cfg))
 
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
@@ -23,6 +32,7 @@ func NewAdapter(ctx context.Context, cfg *config.DB) (*Adapter, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parsing config: %v", err)
 	}
+	configurePool(dbConfig, cfg)
 	pool, err := pgxpool.NewWithConfig(ctxWithTimeout, dbConfig)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to database: %v", err)
@@ -31,7 +41,64 @@ func NewAdapter(ctx context.Context, cfg *config.DB) (*Adapter, error) {
 		return nil, fmt.Errorf("unable to ping database: %v", err)
 	}
 
-	return &Adapter{
-		Pool: pool,
-	}, nil
+	adapter := &Adapter{
+		Pool:         pool,
+		QueryTimeout: cfg.QueryTimeout,
+	}
+	if len(cfg.Replicas) > 0 {
+		adapter.replicas, err = newReplicaSet(ctx, cfg)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+	return adapter, nil
+}
+
+// configurePool applies the pool settings shared by the primary and the replicas.
+func configurePool(dbConfig *pgxpool.Config, cfg *config.DB) {
+	dbConfig.MaxConns = int32(cfg.MaxConns)
+	dbConfig.MinConns = int32(cfg.MinConns)
+	dbConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	dbConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	dbConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	if cfg.StatementTimeout > 0 {
+		dbConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	dbConfig.ConnConfig.Tracer = otelpgx.NewTracer()
+	dbConfig.AfterConnect = registerTypes
+}
+
+// Close stops the replica health checks and closes every pool.
+func (a *Adapter) Close() {
+	if a.replicas != nil {
+		a.replicas.close()
+	}
+	a.Pool.Close()
+}
+
+// ReplicaStatus reports the state of each read replica, in configuration order.
+func (a *Adapter) ReplicaStatus() []domain.ReplicaStatus {
+	if a.replicas == nil {
+		return nil
+	}
+	return a.replicas.status()
+}
+
+// connURL returns the URL for connecting to the database described by cfg, with the
+// scheme expected by the driver that will use it.
+func connURL(scheme string, cfg *config.DB) string {
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	dsn := url.URL{
+		Scheme:   scheme,
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:     cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
 }
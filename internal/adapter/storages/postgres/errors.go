@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+// SQLSTATE codes translated by TranslateError.
+const (
+	codeNotNullViolation     = "23502"
+	codeForeignKeyViolation  = "23503"
+	codeUniqueViolation      = "23505"
+	codeCheckViolation       = "23514"
+	codeStringDataTruncation = "22001"
+	codeQueryCanceled        = "57014"
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// constraintErrors maps named constraints to the domain error reported when a write
+// violates them. Constraints that are not listed fall back to the error for their
+// SQLSTATE code.
+var constraintErrors = map[string]error{
+	"movies_year_check": &domain.FieldError{
+		Field:   "year",
+		Message: "year must be between 1888 and the current year",
+		Err:     domain.ErrorValidation,
+	},
+	"movies_runtime_check": &domain.FieldError{
+		Field:   "runtime",
+		Message: "runtime must not be negative",
+		Err:     domain.ErrorValidation,
+	},
+	"genres_length_check": &domain.FieldError{
+		Field:   "genres",
+		Message: "genres must contain between 1 and 5 items",
+		Err:     domain.ErrorValidation,
+	},
+	"users_email_key": domain.ErrDuplicatedEmail,
+}
+
+// TranslateError converts an error returned by pgx into a domain error. Queries that
+// ran out of time, on either side, are wrapped in domain.ErrQueryTimeout and errors
+// with no domain meaning in domain.ErrInternalServer, keeping the original error for
+// logging and for the transaction retry check.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrDataNotFound
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", domain.ErrQueryTimeout, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if domainErr, ok := constraintErrors[pgErr.ConstraintName]; ok {
+			return domainErr
+		}
+		switch pgErr.Code {
+		case codeUniqueViolation:
+			return domain.ErrConflictingData
+		case codeForeignKeyViolation:
+			return domain.ErrDataNotFound
+		case codeCheckViolation, codeNotNullViolation, codeStringDataTruncation:
+			return domain.ErrorValidation
+		case codeQueryCanceled:
+			return fmt.Errorf("%w: %w", domain.ErrQueryTimeout, err)
+		}
+	}
+	return fmt.Errorf("%w: %w", domain.ErrInternalServer, err)
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres/migrations"
+)
+
+// ErrSchemaOutdated is returned by CheckSchema when migrations are pending.
+var ErrSchemaOutdated = errors.New("database schema is behind the embedded migrations")
+
+// Migrator applies the migrations embedded in the binary. The database driver holds a
+// Postgres advisory lock while it migrates, so concurrent runners wait for each other
+// instead of interleaving.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+func NewMigrator(cfg *config.DB) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read embedded migrations: %v", err)
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, connURL("pgx5", cfg))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %v", err)
+	}
+	m.Log = migrateLogger{}
+
+	// A second source instance keeps Latest independent of the one migrate reads from.
+	latestSrc, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read embedded migrations: %v", err)
+	}
+	return &Migrator{
+		m:      m,
+		source: latestSrc,
+	}, nil
+}
+
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down rolls back the last steps migrations.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("down needs a positive number of steps, got %d", steps)
+	}
+	return ignoreNoChange(m.m.Steps(-steps))
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.m.Migrate(version))
+}
+
+// Force sets the recorded version without running any migration, to recover from a
+// failed migration that left the schema dirty.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Version returns the applied version and whether the last migration failed halfway.
+// It is zero when no migration has been applied.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Latest returns the highest embedded migration version.
+func (m *Migrator) Latest() (uint, error) {
+	version, err := m.source.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := m.source.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// CheckSchema returns ErrSchemaOutdated when the database is dirty or behind the
+// embedded migrations.
+func (m *Migrator) CheckSchema() error {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	latest, err := m.Latest()
+	if err != nil {
+		return err
+	}
+	if dirty || current < latest {
+		return fmt.Errorf("%w: at version %d (dirty: %t), latest is %d", ErrSchemaOutdated, current, dirty, latest)
+	}
+	return nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr, m.source.Close())
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	fmt.Printf(format, v...)
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
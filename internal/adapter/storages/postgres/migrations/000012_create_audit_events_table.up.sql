@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+	id bigserial PRIMARY KEY,
+	created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+	actor_id bigint REFERENCES users ON DELETE SET NULL,
+	action text NOT NULL,
+	target_type text NOT NULL DEFAULT '',
+	target_id text NOT NULL DEFAULT '',
+	ip text NOT NULL DEFAULT '',
+	user_agent text NOT NULL DEFAULT '',
+	request_id text NOT NULL DEFAULT '',
+	metadata jsonb NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_created_at_idx ON audit_events (actor_id, created_at);
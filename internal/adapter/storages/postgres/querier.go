@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+// Querier is implemented by both the pool and a transaction, so repositories can run
+// the same statements inside and outside a unit of work.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// Conn returns the transaction carried by ctx, or the primary pool when there is
+// none. Each query run through it is cancelled after the adapter's QueryTimeout.
+func (a *Adapter) Conn(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return a.withTimeout(tx)
+	}
+	return a.withTimeout(a.Pool)
+}
+
+// Reader returns a connection for a read that a replica may serve. Reads go to the
+// healthy replicas in turn when ctx allows replica reads and carries no transaction,
+// and fall back to Conn otherwise, including when no replica is healthy.
+func (a *Adapter) Reader(ctx context.Context) Querier {
+	if a.replicas == nil || !domain.ReplicaReadsAllowed(ctx) {
+		return a.Conn(ctx)
+	}
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return a.Conn(ctx)
+	}
+	if pool := a.replicas.pick(); pool != nil {
+		return a.withTimeout(pool)
+	}
+	return a.Conn(ctx)
+}
+
+func (a *Adapter) withTimeout(q Querier) Querier {
+	if a.QueryTimeout <= 0 {
+		return q
+	}
+	return &timeoutQuerier{q: q, timeout: a.QueryTimeout}
+}
+
+// timeoutQuerier runs each query under its own deadline. The deadline is released
+// once the result has been read: after Scan for a row, and on Close for rows and
+// batches.
+type timeoutQuerier struct {
+	q       Querier
+	timeout time.Duration
+}
+
+func (t *timeoutQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.q.Exec(ctx, sql, args...)
+}
+
+func (t *timeoutQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	rows, err := t.q.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (t *timeoutQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	return &timeoutRow{row: t.q.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+func (t *timeoutQuerier) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	return &timeoutBatchResults{BatchResults: t.q.SendBatch(ctx, b), cancel: cancel}
+}
+
+type timeoutRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
+
+type timeoutRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+type timeoutBatchResults struct {
+	pgx.BatchResults
+	cancel context.CancelFunc
+}
+
+func (r *timeoutBatchResults) Close() error {
+	defer r.cancel()
+	return r.BatchResults.Close()
+}
@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/metrics"
+)
+
+// replicaStateQuery reports whether the server is a standby, whether its WAL receiver
+// is streaming from the primary, and how many seconds its replay trails what it
+// received. While streaming, a replica that has replayed everything it received
+// reports no lag, even when the primary has been idle since the last replayed
+// transaction; once the receiver disconnects that no longer holds, so such a replica
+// is taken out of rotation whatever its lag.
+const replicaStateQuery = `
+	SELECT
+		pg_is_in_recovery(),
+		COALESCE((SELECT status = 'streaming' FROM pg_stat_wal_receiver), false),
+		COALESCE(
+			CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+			END, 0)::float8
+`
+
+var (
+	errReplicaIsPrimary    = errors.New("server is not in recovery, the replica URL points at a primary")
+	errReplicaNotStreaming = errors.New("WAL receiver is not streaming from the primary")
+)
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+	lag     atomic.Int64
+}
+
+// replicaSet spreads reads over the healthy replicas in turn. A background check
+// takes replicas that are unreachable, not streaming from the primary or lagging more
+// than maxLag out of rotation, and puts them back once they recover. A URL that points
+// at a primary never enters rotation.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newReplicaSet(ctx context.Context, cfg *config.DB) (*replicaSet, error) {
+	s := &replicaSet{
+		maxLag: cfg.ReplicaMaxLag,
+		stop:   make(chan struct{}),
+	}
+	for _, rawURL := range cfg.Replicas {
+		name := replicaName(rawURL)
+		dbConfig, err := pgxpool.ParseConfig(rawURL)
+		if err != nil {
+			s.close()
+			return nil, fmt.Errorf("error parsing config of replica %s: %v", name, err)
+		}
+		configurePool(dbConfig, cfg)
+		// Connections are made lazily, so an unreachable replica only stays out of
+		// rotation instead of stopping the server from starting.
+		pool, err := pgxpool.NewWithConfig(ctx, dbConfig)
+		if err != nil {
+			s.close()
+			return nil, fmt.Errorf("unable to create pool for replica %s: %v", name, err)
+		}
+		r := &replica{name: name, pool: pool}
+		r.healthy.Store(true)
+		s.replicas = append(s.replicas, r)
+	}
+
+	// Replicas start out healthy so the first check, made before any traffic is
+	// served, logs the ones it takes out of rotation.
+	s.checkAll(ctx, cfg.ReplicaCheckInterval)
+	s.wg.Add(1)
+	go s.watch(cfg.ReplicaCheckInterval)
+	return s, nil
+}
+
+// pick returns the pool of the next healthy replica, or nil when none is healthy.
+func (s *replicaSet) pick() *pgxpool.Pool {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := s.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return nil
+}
+
+func (s *replicaSet) status() []domain.ReplicaStatus {
+	status := make([]domain.ReplicaStatus, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		status = append(status, domain.ReplicaStatus{
+			Name:    r.name,
+			Healthy: r.healthy.Load(),
+			Lag:     time.Duration(r.lag.Load()),
+		})
+	}
+	return status
+}
+
+func (s *replicaSet) watch(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkAll(context.Background(), interval)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *replicaSet) checkAll(ctx context.Context, timeout time.Duration) {
+	for _, r := range s.replicas {
+		s.check(ctx, r, timeout)
+	}
+}
+
+func (s *replicaSet) check(ctx context.Context, r *replica, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log := logger.GetLogger().Named(logger.DB)
+	var inRecovery, streaming bool
+	var lagSeconds float64
+	err := r.pool.QueryRow(ctx, replicaStateQuery).Scan(&inRecovery, &streaming, &lagSeconds)
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	if err == nil {
+		r.lag.Store(int64(lag))
+		metrics.DBReplicaLag.WithLabelValues(r.name).Set(lagSeconds)
+		switch {
+		case !inRecovery:
+			err = errReplicaIsPrimary
+		case !streaming:
+			err = errReplicaNotStreaming
+		}
+	}
+	healthy := err == nil && lag <= s.maxLag
+	metrics.DBReplicaHealthy.WithLabelValues(r.name).Set(boolToFloat(healthy))
+
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	switch {
+	case healthy:
+		log.Info("replica back in rotation", "replica", r.name, "lag", lag)
+	case err != nil:
+		log.Warn("replica out of rotation", "replica", r.name, "err", err)
+	default:
+		log.Warn("replica out of rotation", "replica", r.name, "lag", lag, "max_lag", s.maxLag)
+	}
+}
+
+func (s *replicaSet) close() {
+	close(s.stop)
+	s.wg.Wait()
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
+}
+
+// replicaName identifies a replica by its address, keeping credentials out of logs
+// and the health endpoint.
+func replicaName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "replica"
+	}
+	return u.Host
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
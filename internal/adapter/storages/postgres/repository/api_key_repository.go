@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type APIKeyRepository struct {
+	db *postgres.Adapter
+}
+
+func NewAPIKeyRepository(db *postgres.Adapter) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+func (r *APIKeyRepository) Insert(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, hash, permissions, expiry)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	args := []any{
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		key.Permissions,
+		key.Expiry,
+	}
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	query := `
+		SELECT id, created_at, user_id, name, prefix, hash, permissions, expiry, last_used_at, revoked_at
+		FROM api_keys
+		WHERE prefix = $1
+	`
+	rows, err := r.db.Conn(ctx).Query(ctx, query, prefix)
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	key, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[domain.APIKey])
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepository) GetAllForUser(ctx context.Context, userID int64) ([]*domain.APIKey, error) {
+	query := `
+		SELECT id, created_at, user_id, name, prefix, hash, permissions, expiry, last_used_at, revoked_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Conn(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[domain.APIKey])
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id, userID int64) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	result, err := r.db.Conn(ctx).Exec(ctx, query, id, userID)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed records that the key was used. Writes are limited to one a minute per
+// key so busy clients do not turn every request into an UPDATE.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
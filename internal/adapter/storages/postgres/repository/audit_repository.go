@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type AuditRepository struct {
+	db *postgres.Adapter
+}
+
+func NewAuditRepository(db *postgres.Adapter) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+func (r *AuditRepository) Insert(ctx context.Context, event *domain.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (actor_id, action, target_type, target_id, ip, user_agent, request_id, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	args := []any{
+		event.ActorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.IP,
+		event.UserAgent,
+		event.RequestID,
+		metadata,
+	}
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *AuditRepository) GetAll(ctx context.Context, auditFilter domain.AuditFilter, filter domain.Filter) ([]*domain.AuditEvent, domain.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, created_at, actor_id, action, target_type, target_id,
+			ip, user_agent, request_id, metadata
+		FROM audit_events
+		WHERE (actor_id = $1 OR $1 = 0)
+		AND (action = $2 OR $2 = '')
+		AND (created_at >= $3 OR $3 IS NULL)
+		AND (created_at < $4 OR $4 IS NULL)
+		ORDER BY %s %s, id ASC
+		LIMIT $5 OFFSET $6`, filter.SortColumn(), filter.SortDirection())
+	args := []any{
+		auditFilter.ActorID,
+		auditFilter.Action,
+		auditFilter.From,
+		auditFilter.To,
+		filter.Limit(),
+		filter.Offset(),
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, domain.Metadata{}, postgres.TranslateError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	events := make([]*domain.AuditEvent, 0)
+	for rows.Next() {
+		var event domain.AuditEvent
+		err := rows.Scan(
+			&totalRecords,
+			&event.ID,
+			&event.CreatedAt,
+			&event.ActorID,
+			&event.Action,
+			&event.TargetType,
+			&event.TargetID,
+			&event.IP,
+			&event.UserAgent,
+			&event.RequestID,
+			&event.Metadata,
+		)
+		if err != nil {
+			return nil, domain.Metadata{}, postgres.TranslateError(err)
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, domain.Metadata{}, postgres.TranslateError(err)
+	}
+	metadata := domain.CalculateMetadata(totalRecords, filter.Page, filter.Size)
+	return events, metadata, nil
+}
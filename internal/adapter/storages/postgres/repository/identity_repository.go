@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type IdentityRepository struct {
+	db *postgres.Adapter
+}
+
+func NewIdentityRepository(db *postgres.Adapter) *IdentityRepository {
+	return &IdentityRepository{
+		db: db,
+	}
+}
+
+func (r *IdentityRepository) Get(ctx context.Context, issuer, subject string) (*domain.Identity, error) {
+	query := `
+		SELECT issuer, subject, user_id, email, created_at
+		FROM user_identities
+		WHERE issuer = $1 AND subject = $2
+	`
+	var identity domain.Identity
+	err := r.db.Conn(ctx).QueryRow(ctx, query, issuer, subject).Scan(
+		&identity.Issuer,
+		&identity.Subject,
+		&identity.UserID,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDataNotFound
+		}
+		return nil, postgres.TranslateError(err)
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepository) Insert(ctx context.Context, identity *domain.Identity) error {
+	query := `
+		INSERT INTO user_identities (issuer, subject, user_id, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	args := []any{
+		identity.Issuer,
+		identity.Subject,
+		identity.UserID,
+		identity.Email,
+	}
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&identity.CreatedAt)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *IdentityRepository) InsertState(ctx context.Context, state *domain.OIDCLoginState) error {
+	query := `
+		INSERT INTO oidc_login_states (state, nonce, code_verifier, expiry)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, state.State, state.Nonce, state.CodeVerifier, state.Expiry)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+// TakeState deletes and returns a pending login state so that each callback can be
+// handled only once. Expired states are cleaned up along the way.
+func (r *IdentityRepository) TakeState(ctx context.Context, state string) (*domain.OIDCLoginState, error) {
+	_, err := r.db.Conn(ctx).Exec(ctx, `DELETE FROM oidc_login_states WHERE expiry <= NOW()`)
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+
+	query := `
+		DELETE FROM oidc_login_states
+		WHERE state = $1
+		RETURNING state, nonce, code_verifier, expiry
+	`
+	var loginState domain.OIDCLoginState
+	err = r.db.Conn(ctx).QueryRow(ctx, query, state).Scan(
+		&loginState.State,
+		&loginState.Nonce,
+		&loginState.CodeVerifier,
+		&loginState.Expiry,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDataNotFound
+		}
+		return nil, postgres.TranslateError(err)
+	}
+	return &loginState, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+)
+
+type LoginAttemptRepository struct {
+	db *postgres.Adapter
+}
+
+func NewLoginAttemptRepository(db *postgres.Adapter) *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		db: db,
+	}
+}
+
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, ip string) error {
+	query := `
+		INSERT INTO login_failures (ip)
+		VALUES ($1)
+	`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, ip)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+// GetFailures returns the number of failed logins from ip since the given time and
+// when the latest of them happened.
+func (r *LoginAttemptRepository) GetFailures(ctx context.Context, ip string, since time.Time) (int, time.Time, error) {
+	query := `
+		SELECT count(*), COALESCE(max(created_at), $2)
+		FROM login_failures
+		WHERE ip = $1 AND created_at > $2
+	`
+	var (
+		count int
+		last  time.Time
+	)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, ip, since).Scan(&count, &last)
+	if err != nil {
+		return 0, time.Time{}, postgres.TranslateError(err)
+	}
+	return count, last, nil
+}
+
+// PruneFailures deletes the failed logins recorded at or before the given time and
+// returns how many were removed.
+func (r *LoginAttemptRepository) PruneFailures(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Conn(ctx).Exec(ctx, `DELETE FROM login_failures WHERE created_at <= $1`, before)
+	if err != nil {
+		return 0, postgres.TranslateError(err)
+	}
+	return result.RowsAffected(), nil
+}
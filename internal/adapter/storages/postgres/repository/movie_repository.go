@@ -6,17 +6,22 @@ import (
 	"strings"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/lib/pq"
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
 	"github.com/thaian1234/green_light/internal/core/domain"
-	"github.com/thaian1234/green_light/pkg/util"
 )
 
+// movieListRow is a movies row together with the total number of rows matched by a
+// paginated query.
+type movieListRow struct {
+	TotalRecords int
+	domain.Movie
+}
+
 type MovieRepository struct {
-	db *pgxpool.Pool
+	db *postgres.Adapter
 }
 
-func NewMovieRepository(db *pgxpool.Pool) *MovieRepository {
+func NewMovieRepository(db *postgres.Adapter) *MovieRepository {
 	return &MovieRepository{
 		db: db,
 	}
@@ -32,20 +37,12 @@ func (r *MovieRepository) Insert(ctx context.Context, movie *domain.Movie) error
 		movie.Title,
 		movie.Year,
 		movie.Runtime,
-		pq.Array(movie.Genres),
+		movie.Genres,
 	}
 
-	err := r.db.QueryRow(ctx, query, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				return domain.ErrConflictingData
-			case "foreign_key_violation":
-				return domain.ErrDataNotFound
-			}
-		}
-		return domain.ErrInternalServer
+		return postgres.TranslateError(err)
 	}
 	return nil
 }
@@ -56,28 +53,23 @@ func (r *MovieRepository) GetByID(ctx context.Context, id int64) (*domain.Movie,
 		FROM movies
 		WHERE id = $1
 	`
-	var movie domain.Movie
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&movie.ID,
-		&movie.CreatedAt,
-		&movie.Title,
-		&movie.Year,
-		&movie.Runtime,
-		&movie.Genres,
-		&movie.Version,
-	)
+	rows, err := r.db.Reader(ctx).Query(ctx, query, id)
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	movie, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[domain.Movie])
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, domain.ErrDataNotFound
 		}
-		return nil, domain.ErrInternalServer
+		return nil, postgres.TranslateError(err)
 	}
-	return &movie, nil
+	return movie, nil
 }
 
 func (r *MovieRepository) GetAll(ctx context.Context, title string, genres []string, filter domain.Filter) ([]*domain.Movie, domain.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, created_at, title, year, runtime, genres, version
+		SELECT count(*) OVER() AS total_records, id, created_at, title, year, runtime, genres, version
 		FROM movies
 		WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
 		AND (genres @> $2 OR $2 = '{}')
@@ -85,39 +77,25 @@ func (r *MovieRepository) GetAll(ctx context.Context, title string, genres []str
 		LIMIT $3 OFFSET $4`, filter.SortColumn(), filter.SortDirection())
 	args := []any{
 		strings.TrimSpace(strings.ToLower(title)),
-		pq.Array(genres),
+		genres,
 		filter.Limit(),
 		filter.Offset(),
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := r.db.Reader(ctx).Query(ctx, query, args...)
 	if err != nil {
-		return nil, domain.Metadata{}, domain.ErrInternalServer
+		return nil, domain.Metadata{}, postgres.TranslateError(err)
 	}
-	defer rows.Close()
-
-	totalRecords := 0
-	movies := make([]*domain.Movie, 0)
-	for rows.Next() {
-		var movie domain.Movie
-		err := rows.Scan(
-			&totalRecords,
-			&movie.ID,
-			&movie.CreatedAt,
-			&movie.Title,
-			&movie.Year,
-			&movie.Runtime,
-			&movie.Genres,
-			&movie.Version,
-		)
-		if err != nil {
-			return nil, domain.Metadata{}, domain.ErrInternalServer
-		}
-		movies = append(movies, &movie)
+	listRows, err := pgx.CollectRows(rows, pgx.RowToStructByName[movieListRow])
+	if err != nil {
+		return nil, domain.Metadata{}, postgres.TranslateError(err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, domain.Metadata{}, domain.ErrInternalServer
+	totalRecords := 0
+	movies := make([]*domain.Movie, 0, len(listRows))
+	for i := range listRows {
+		totalRecords = listRows[i].TotalRecords
+		movies = append(movies, &listRows[i].Movie)
 	}
 	metadata := domain.CalculateMetadata(totalRecords, filter.Page, filter.Size)
 	return movies, metadata, nil
@@ -135,19 +113,19 @@ func (r *MovieRepository) Update(ctx context.Context, movie *domain.Movie) error
         RETURNING version
     `
 	args := []any{
-		util.NullString(movie.Title),
-		util.NullUint64(uint64(movie.Year)),
-		util.NullUint64(uint64(movie.Runtime)),
-		pq.Array(movie.Genres),
+		postgres.NullText(movie.Title),
+		postgres.NullInt4(movie.Year),
+		postgres.NullInt4(movie.Runtime),
+		movie.Genres,
 		movie.ID,
 		movie.Version,
 	}
-	err := r.db.QueryRow(ctx, query, args...).Scan(&movie.Version)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&movie.Version)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return domain.ErrUpdateConflict
 		}
-		return domain.ErrInternalServer
+		return postgres.TranslateError(err)
 	}
 	return nil
 }
@@ -156,9 +134,9 @@ func (r *MovieRepository) Delete(ctx context.Context, id int64) error {
 	query := `
 		DELETE FROM movies WHERE id = $1
 	`
-	result, err := r.db.Exec(ctx, query, id)
+	result, err := r.db.Conn(ctx).Exec(ctx, query, id)
 	if err != nil {
-		return domain.ErrInternalServer
+		return postgres.TranslateError(err)
 	}
 	if result.RowsAffected() == 0 {
 		return domain.ErrDataNotFound
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type PermissionRepository struct {
+	db *postgres.Adapter
+}
+
+func NewPermissionRepository(db *postgres.Adapter) *PermissionRepository {
+	return &PermissionRepository{
+		db: db,
+	}
+}
+
+func (r *PermissionRepository) GetAllForUser(ctx context.Context, userID int64) (domain.Permissions, error) {
+	query := `
+		SELECT permissions.code
+		FROM permissions
+		INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
+		WHERE users_permissions.user_id = $1
+		ORDER BY permissions.code
+	`
+	rows, err := r.db.Conn(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	defer rows.Close()
+
+	permissions := make(domain.Permissions, 0)
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, postgres.TranslateError(err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	return permissions, nil
+}
+
+func (r *PermissionRepository) AddForUser(ctx context.Context, userID int64, codes ...string) error {
+	query := `
+		INSERT INTO users_permissions (user_id, permission_id)
+		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING
+	`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, userID, codes)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *PermissionRepository) RemoveForUser(ctx context.Context, userID int64, codes ...string) error {
+	query := `
+		DELETE FROM users_permissions
+		USING permissions
+		WHERE users_permissions.permission_id = permissions.id
+		AND users_permissions.user_id = $1
+		AND permissions.code = ANY($2)
+	`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, userID, codes)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
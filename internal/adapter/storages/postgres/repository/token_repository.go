@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type TokenRepository struct {
+	db *postgres.Adapter
+}
+
+func NewTokenRepository(db *postgres.Adapter) *TokenRepository {
+	return &TokenRepository{
+		db: db,
+	}
+}
+
+func (r *TokenRepository) Insert(ctx context.Context, token *domain.Token) error {
+	query := `
+		INSERT INTO tokens (hash, user_id, expiry, scope)
+		VALUES ($1, $2, $3, $4)
+	`
+	args := []any{
+		token.Hash,
+		token.UserID,
+		token.Expiry,
+		token.Scope,
+	}
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *TokenRepository) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
+	query := `
+		DELETE FROM tokens
+		WHERE scope = $1 AND user_id = $2
+	`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, scope, userID)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
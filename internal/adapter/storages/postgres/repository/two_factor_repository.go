@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type TwoFactorRepository struct {
+	db *postgres.Adapter
+}
+
+func NewTwoFactorRepository(db *postgres.Adapter) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db: db,
+	}
+}
+
+func (r *TwoFactorRepository) Get(ctx context.Context, userID int64) (*domain.TwoFactor, error) {
+	query := `
+		SELECT user_id, created_at, secret, enabled, last_used_step
+		FROM users_two_factor
+		WHERE user_id = $1
+	`
+	var twoFactor domain.TwoFactor
+	err := r.db.Conn(ctx).QueryRow(ctx, query, userID).Scan(
+		&twoFactor.UserID,
+		&twoFactor.CreatedAt,
+		&twoFactor.Secret,
+		&twoFactor.Enabled,
+		&twoFactor.LastUsedStep,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDataNotFound
+		}
+		return nil, postgres.TranslateError(err)
+	}
+	return &twoFactor, nil
+}
+
+// Upsert stores a new, not yet enabled secret for the user. An enabled secret is never
+// overwritten; the user has to disable two-factor authentication first.
+func (r *TwoFactorRepository) Upsert(ctx context.Context, twoFactor *domain.TwoFactor) error {
+	query := `
+		INSERT INTO users_two_factor (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = NOW(), last_used_step = 0
+		WHERE users_two_factor.enabled = FALSE
+		RETURNING created_at
+	`
+	err := r.db.Conn(ctx).QueryRow(ctx, query, twoFactor.UserID, twoFactor.Secret).Scan(&twoFactor.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrConflictingData
+		}
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE users_two_factor
+		SET enabled = TRUE
+		WHERE user_id = $1
+	`
+	result, err := r.db.Conn(ctx).Exec(ctx, query, userID)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+	return nil
+}
+
+// MarkStepUsed records step as the latest accepted code. It reports false when the
+// step, or a later one, was already used, which is how replays are rejected even
+// across concurrent requests.
+func (r *TwoFactorRepository) MarkStepUsed(ctx context.Context, userID, step int64) (bool, error) {
+	query := `
+		UPDATE users_two_factor
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+	result, err := r.db.Conn(ctx).Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, postgres.TranslateError(err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID int64) error {
+	_, err := r.db.Conn(ctx).Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	result, err := r.db.Conn(ctx).Exec(ctx, `DELETE FROM users_two_factor WHERE user_id = $1`, userID)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes [][]byte) error {
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM recovery_codes WHERE user_id = $1`, userID)
+	for _, hash := range hashes {
+		batch.Queue(`INSERT INTO recovery_codes (hash, user_id) VALUES ($1, $2)`, hash, userID)
+	}
+	if err := r.db.Conn(ctx).SendBatch(ctx, batch).Close(); err != nil {
+		return postgres.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, hash []byte) (bool, error) {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND hash = $2 AND used_at IS NULL
+	`
+	result, err := r.db.Conn(ctx).Exec(ctx, query, userID, hash)
+	if err != nil {
+		return false, postgres.TranslateError(err)
+	}
+	return result.RowsAffected() == 1, nil
+}
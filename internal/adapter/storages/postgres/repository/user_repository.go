@@ -3,21 +3,46 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thaian1234/green_light/internal/adapter/storages/postgres"
 	"github.com/thaian1234/green_light/internal/core/domain"
 	"github.com/thaian1234/green_light/pkg/logger"
-	"github.com/thaian1234/green_light/pkg/util"
 )
 
+// userRow is a users row as returned by the queries below. The password hash is
+// scanned on its own because it is nested in domain.Password, and TotalRecords is
+// only selected by paginated queries.
+type userRow struct {
+	domain.User
+	PasswordHash []byte
+	TotalRecords int
+}
+
+func (row *userRow) toDomain() *domain.User {
+	user := row.User
+	user.Password.Hash = row.PasswordHash
+	return &user
+}
+
+// collectUser scans the single user returned by rows, reporting ErrDataNotFound when
+// there is none.
+func collectUser(rows pgx.Rows) (*domain.User, error) {
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByNameLax[userRow])
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	return row.toDomain(), nil
+}
+
 type UserRepository struct {
-	db *pgxpool.Pool
+	db *postgres.Adapter
 }
 
-func NewUserRepository(db *pgxpool.Pool) *UserRepository {
+func NewUserRepository(db *postgres.Adapter) *UserRepository {
 	return &UserRepository{
 		db: db,
 	}
@@ -35,79 +60,193 @@ func (r *UserRepository) Insert(ctx context.Context, user *domain.User) error {
 		user.Password.Hash,
 		user.Activated,
 	}
-	logger.Debug("Inserting user", "args", args)
-	err := r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
+	logger.FromContext(ctx).Named(logger.DB).Debug("Inserting user", "email", user.Email)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
 	if err != nil {
-		logger.Debug("Inserting user", "err", err)
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch {
-			case pgErr.Code == "23505" && strings.Contains(pgErr.Message, "users_email_key"):
-				return domain.ErrDuplicatedEmail
-			}
-		}
-		if errors.Is(err, pgx.ErrNoRows) {
-			return domain.ErrDataNotFound
-		}
-		return domain.ErrInternalServer
+		logger.FromContext(ctx).Named(logger.DB).Debug("Inserting user", "err", err)
+		return postgres.TranslateError(err)
 	}
 	return nil
 }
 
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	query := `
+		SELECT id, created_at, name, email, pending_email, password_hash, activated,
+			failed_login_attempts, locked_until, deactivated_at, version
+		FROM users
+		WHERE id = $1
+	`
+	rows, err := r.db.Reader(ctx).Query(ctx, query, id)
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	return collectUser(rows)
+}
+
+// GetAll returns a page of users whose name or email contains search, matched case
+// insensitively. An empty search matches every user.
+func (r *UserRepository) GetAll(ctx context.Context, search string, filter domain.Filter) ([]*domain.User, domain.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER() AS total_records, id, created_at, name, email, pending_email, password_hash, activated,
+			failed_login_attempts, locked_until, deactivated_at, version
+		FROM users
+		WHERE (name ILIKE '%%' || $1 || '%%' OR email::text ILIKE '%%' || $1 || '%%' OR $1 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3`, filter.SortColumn(), filter.SortDirection())
+	args := []any{
+		strings.TrimSpace(search),
+		filter.Limit(),
+		filter.Offset(),
+	}
+
+	rows, err := r.db.Reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, domain.Metadata{}, postgres.TranslateError(err)
+	}
+	userRows, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[userRow])
+	if err != nil {
+		return nil, domain.Metadata{}, postgres.TranslateError(err)
+	}
+
+	totalRecords := 0
+	users := make([]*domain.User, 0, len(userRows))
+	for i := range userRows {
+		totalRecords = userRows[i].TotalRecords
+		users = append(users, userRows[i].toDomain())
+	}
+	metadata := domain.CalculateMetadata(totalRecords, filter.Page, filter.Size)
+	return users, metadata, nil
+}
+
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, created_at, name, email, password_hash, activated, version
+		SELECT id, created_at, name, email, pending_email, password_hash, activated,
+			failed_login_attempts, locked_until, deactivated_at, version
 		FROM users
 		WHERE email = $1
 	`
-	var user domain.User
-	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.ID,
-		&user.CreatedAt,
-		&user.Name,
-		&user.Email,
-		&user.Password.Hash,
-		&user.Activated,
-		&user.Version,
-	)
+	rows, err := r.db.Reader(ctx).Query(ctx, query, email)
 	if err != nil {
-		switch {
-		case err == pgx.ErrNoRows:
-			return nil, domain.ErrDataNotFound
-		default:
-			return nil, domain.ErrInternalServer
-		}
+		return nil, postgres.TranslateError(err)
 	}
-	return &user, nil
+	return collectUser(rows)
 }
 
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
 		SET name = COALESCE($1, name),
-			email = COALESCE($2, email),
+			pending_email = $2,
 			password_hash = COALESCE($3, password_hash),
 			activated = COALESCE($4, activated),
+			deactivated_at = $5,
 			version = version + 1
-		WHERE id = $5 and version = $6
+		WHERE id = $6 and version = $7
 		RETURNING version
 	`
 	args := []any{
-		util.NullString(user.Name),
-		util.NullString(user.Email),
-		util.NullString(string(user.Password.Hash)),
+		postgres.NullText(user.Name),
+		user.PendingEmail,
+		user.Password.Hash,
 		user.Activated,
+		user.DeactivatedAt,
 		user.ID,
 		user.Version,
 	}
-	err := r.db.QueryRow(ctx, query, args...).Scan(&user.Version)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&user.Version)
 	if err != nil {
 		switch {
 		case err == pgx.ErrNoRows:
 			return domain.ErrUpdateConflict
 		default:
-			return domain.ErrInternalServer
+			return postgres.TranslateError(err)
+		}
+	}
+	return nil
+}
+
+// ConfirmPendingEmail swaps the user's email for the pending one and clears it. The
+// citext unique constraint on email still applies, so an address that was claimed by
+// another account in the meantime is reported as ErrDuplicatedEmail.
+func (r *UserRepository) ConfirmPendingEmail(ctx context.Context, user *domain.User) error {
+	query := `
+		UPDATE users
+		SET email = pending_email,
+			pending_email = NULL,
+			version = version + 1
+		WHERE id = $1 AND version = $2 AND pending_email IS NOT NULL
+		RETURNING email, version
+	`
+	err := r.db.Conn(ctx).QueryRow(ctx, query, user.ID, user.Version).Scan(&user.Email, &user.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrUpdateConflict
+		}
+		return postgres.TranslateError(err)
+	}
+	user.PendingEmail = nil
+	return nil
+}
+
+func (r *UserRepository) GetForToken(ctx context.Context, scope, plaintext string) (*domain.User, error) {
+	query := `
+		SELECT users.id, users.created_at, users.name, users.email, users.pending_email,
+			users.password_hash, users.activated, users.failed_login_attempts, users.locked_until,
+			users.deactivated_at, users.version
+		FROM users
+		INNER JOIN tokens ON users.id = tokens.user_id
+		WHERE tokens.hash = $1
+		AND tokens.scope = $2
+		AND tokens.expiry > $3
+	`
+	args := []any{
+		domain.HashToken(plaintext),
+		scope,
+		time.Now(),
+	}
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, postgres.TranslateError(err)
+	}
+	return collectUser(rows)
+}
+
+// IncrementFailedLogins records a failed login for the user and returns the number of
+// consecutive failures. The version is left untouched so that failed logins never
+// cause edit conflicts for the account owner.
+func (r *UserRepository) IncrementFailedLogins(ctx context.Context, id int64) (int, error) {
+	query := `
+		UPDATE users
+		SET failed_login_attempts = failed_login_attempts + 1
+		WHERE id = $1
+		RETURNING failed_login_attempts
+	`
+	var attempts int
+	err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, domain.ErrDataNotFound
 		}
+		return 0, postgres.TranslateError(err)
+	}
+	return attempts, nil
+}
+
+// SetLockedUntil locks the account until the given time, or unlocks it when until is
+// nil, and resets the consecutive failure counter.
+func (r *UserRepository) SetLockedUntil(ctx context.Context, id int64, until *time.Time) error {
+	query := `
+		UPDATE users
+		SET failed_login_attempts = 0,
+			locked_until = $2
+		WHERE id = $1
+	`
+	result, err := r.db.Conn(ctx).Exec(ctx, query, id, until)
+	if err != nil {
+		return postgres.TranslateError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
 	}
 	return nil
 }
@@ -116,9 +255,9 @@ func (r *UserRepository) Delete(ctx context.Context, id int64) error {
 	query := `
 		DELETE FROM users WHERE id = $1
 	`
-	result, err := r.db.Exec(ctx, query, id)
+	result, err := r.db.Conn(ctx).Exec(ctx, query, id)
 	if err != nil {
-		return domain.ErrInternalServer
+		return postgres.TranslateError(err)
 	}
 	if result.RowsAffected() == 0 {
 		return domain.ErrDataNotFound
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	maxTxRetries   = 3
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+type txKey struct{}
+
+type TxManager struct {
+	db *Adapter
+}
+
+func NewTxManager(db *Adapter) *TxManager {
+	return &TxManager{
+		db: db,
+	}
+}
+
+// WithinTx runs fn in a transaction that is committed when fn returns nil and rolled
+// back otherwise. Inside an existing transaction it uses a savepoint instead. A
+// top-level transaction that fails with a serialization failure or deadlock is retried
+// a few times, running fn again with the same arguments, so fn must restore any state
+// it changed in memory before touching it again.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return runTx(ctx, tx.Begin, fn)
+	}
+
+	var err error
+	for attempt := 0; attempt <= maxTxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * txRetryBackoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		err = runTx(ctx, m.db.Begin, fn)
+		if !isRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(ctx context.Context) error) (err error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Roll back even when ctx was cancelled, so the connection goes back to the pool
+	// clean.
+	rollback := func() {
+		_ = tx.Rollback(context.WithoutCancel(ctx))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		rollback()
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// isRetryable reports whether err is a serialization failure or deadlock, after which
+// running the transaction again can succeed.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+// registerTypes maps domain types onto the PostgreSQL types they are stored as, so
+// they can be passed as query arguments and scanned into without conversions in the
+// repositories. It runs for every new connection in the pool.
+func registerTypes(_ context.Context, conn *pgx.Conn) error {
+	typeMap := conn.TypeMap()
+	typeMap.RegisterDefaultPgType(domain.Runtime(0), "int4")
+	typeMap.RegisterDefaultPgType(domain.Permissions{}, "_text")
+	return nil
+}
+
+// NullText returns s as a nullable text value that is NULL when s is empty. With
+// COALESCE in an UPDATE it leaves the column unchanged when no value was supplied.
+func NullText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// NullInt4 returns v as a nullable int4 value that is NULL when v is zero.
+func NullInt4[T ~int32](v T) pgtype.Int4 {
+	return pgtype.Int4{Int32: int32(v), Valid: v != 0}
+}
@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/thaian1234/green_light/config"
+)
+
+type Adapter struct {
+	*redis.Client
+}
+
+func NewAdapter(ctx context.Context, cfg *config.Redis) (*Adapter, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+	})
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	if err := client.Ping(ctxWithTimeout).Err(); err != nil {
+		return nil, fmt.Errorf("unable to ping redis: %v", err)
+	}
+
+	return &Adapter{
+		Client: client,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix marks a credential as an API key rather than an authentication token.
+const APIKeyPrefix = "gl_"
+
+var apiKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type APIKey struct {
+	ID          int64       `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UserID      int64       `json:"-"`
+	Name        string      `json:"name"`
+	Prefix      string      `json:"prefix"`
+	Plaintext   string      `json:"key,omitempty" db:"-"`
+	Hash        []byte      `json:"-"`
+	Permissions Permissions `json:"permissions"`
+	Expiry      *time.Time  `json:"expiry,omitempty"`
+	LastUsedAt  *time.Time  `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time  `json:"revoked_at,omitempty"`
+}
+
+// GenerateAPIKey creates a key of the form gl_<prefix>_<secret>. The prefix is stored
+// in clear to look the key up and to let owners recognise it; the whole key is only
+// ever stored hashed.
+func GenerateAPIKey(userID int64, name string, permissions Permissions, expiry *time.Time) (*APIKey, error) {
+	prefixBytes := make([]byte, 5)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, err
+	}
+	secretBytes := make([]byte, 20)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, err
+	}
+
+	prefix := strings.ToLower(apiKeyEncoding.EncodeToString(prefixBytes))
+	secret := strings.ToLower(apiKeyEncoding.EncodeToString(secretBytes))
+	plaintext := APIKeyPrefix + prefix + "_" + secret
+
+	return &APIKey{
+		UserID:      userID,
+		Name:        name,
+		Prefix:      prefix,
+		Plaintext:   plaintext,
+		Hash:        HashToken(plaintext),
+		Permissions: permissions,
+		Expiry:      expiry,
+	}, nil
+}
+
+// IsAPIKey reports whether a credential looks like an API key.
+func IsAPIKey(plaintext string) bool {
+	return strings.HasPrefix(plaintext, APIKeyPrefix)
+}
+
+// ParseAPIKeyPrefix extracts the lookup prefix from a plaintext API key.
+func ParseAPIKeyPrefix(plaintext string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(plaintext, APIKeyPrefix), "_")
+	if !IsAPIKey(plaintext) || len(parts) != 2 || len(parts[0]) != 8 || len(parts[1]) != 32 {
+		return "", ErrInvalidToken
+	}
+	return parts[0], nil
+}
+
+func (k *APIKey) IsActive() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.Expiry == nil || k.Expiry.After(time.Now())
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AuditUserRegistered         = "user.registered"
+	AuditUserUpdated            = "user.updated"
+	AuditUserDeleted            = "user.deleted"
+	AuditUserActivated          = "user.activated"
+	AuditUserDeactivated        = "user.deactivated"
+	AuditEmailChangeRequested   = "user.email_change_requested"
+	AuditEmailChanged           = "user.email_changed"
+	AuditPasswordResetRequested = "user.password_reset_requested"
+	AuditPasswordReset          = "user.password_reset"
+	AuditTokenIssued            = "token.issued"
+	AuditLoginFailed            = "login.failed"
+	AuditAccountLocked          = "user.locked"
+	AuditPermissionGranted      = "permission.granted"
+	AuditPermissionRevoked      = "permission.revoked"
+	AuditTwoFactorEnabled       = "two_factor.enabled"
+	AuditTwoFactorDisabled      = "two_factor.disabled"
+	AuditAPIKeyCreated          = "api_key.created"
+	AuditAPIKeyRevoked          = "api_key.revoked"
+	AuditIdentityLinked         = "identity.linked"
+	AuditMovieCreated           = "movie.created"
+	AuditMovieUpdated           = "movie.updated"
+	AuditMovieDeleted           = "movie.deleted"
+)
+
+const (
+	AuditTargetUser     = "user"
+	AuditTargetMovie    = "movie"
+	AuditTargetAPIKey   = "api_key"
+	AuditTargetIdentity = "identity"
+)
+
+type AuditEvent struct {
+	ID         int64          `json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ActorID    *int64         `json:"actor_id,omitempty"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type,omitempty"`
+	TargetID   string         `json:"target_id,omitempty"`
+	IP         string         `json:"ip,omitempty"`
+	UserAgent  string         `json:"user_agent,omitempty"`
+	RequestID  string         `json:"request_id,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+type AuditFilter struct {
+	ActorID int64
+	Action  string
+	From    *time.Time
+	To      *time.Time
+}
+
+// AuditActor describes who is making a request. It travels in the context so the
+// service layer can attribute audit events without depending on HTTP types.
+type AuditActor struct {
+	UserID    int64
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type auditActorKey struct{}
+
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func AuditActorFromContext(ctx context.Context) AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor
+}
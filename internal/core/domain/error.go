@@ -18,4 +18,29 @@ var (
 	ErrInvalidToken       = errors.New("access token is invalid")
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrDuplicatedEmail    = errors.New("email already exists")
+	ErrInactiveAccount    = errors.New("user account must be activated to access the resource")
+	ErrAccountLocked      = errors.New("user account is temporarily locked")
+	ErrTooManyAttempts    = errors.New("too many failed login attempts")
+	ErrUnverifiedIdentity = errors.New("identity provider did not verify the email address")
+	ErrIdentityProvider   = errors.New("identity provider login failed")
+	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
+	ErrServiceUnavailable = errors.New("service is temporarily unavailable")
+	ErrQueryTimeout       = errors.New("the database did not respond in time")
 )
+
+// FieldError reports that the value of a single field was rejected, for example by a
+// database check constraint. It wraps the error that decides how it is handled, such
+// as ErrorValidation.
+type FieldError struct {
+	Field   string
+	Message string
+	Err     error
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
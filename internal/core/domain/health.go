@@ -1,7 +1,18 @@
 package domain
 
+import "time"
+
 type HealthStatus struct {
-	Environment string `json:"environment"`
-	Version     string `json:"version"`
-	Status      string `json:"status"`
+	Environment string          `json:"environment"`
+	Version     string          `json:"version"`
+	Status      string          `json:"status"`
+	Replicas    []ReplicaStatus `json:"replicas,omitempty"`
+}
+
+// ReplicaStatus is the state of a database read replica at its last health check.
+// Lag is how far the replica's replay trails the primary.
+type ReplicaStatus struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Lag     time.Duration `json:"lag"`
 }
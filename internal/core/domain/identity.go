@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+// Identity links an account at an external OpenID Connect provider to a user.
+type Identity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	UserID    int64     `json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExternalIdentity holds the verified claims of an ID token.
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDCLoginState is kept between redirecting the user to the provider and handling
+// the callback: state guards against CSRF, nonce binds the ID token to this login and
+// the code verifier is the PKCE secret.
+type OIDCLoginState struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	Expiry       time.Time
+}
+
+func NewOIDCLoginState(ttl time.Duration) (*OIDCLoginState, error) {
+	values := make([]string, 3)
+	for i := range values {
+		randomBytes := make([]byte, 32)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(randomBytes)
+	}
+	return &OIDCLoginState{
+		State:        values[0],
+		Nonce:        values[1],
+		CodeVerifier: values[2],
+		Expiry:       time.Now().Add(ttl),
+	}, nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginPolicy holds the thresholds used to slow down and lock out repeated failed
+// logins.
+type LoginPolicy struct {
+	MaxAccountAttempts int
+	MaxIPAttempts      int
+	Window             time.Duration
+	LockoutDuration    time.Duration
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+}
+
+// Delay returns how long a client must wait after its latest failure before trying
+// again, doubling with every failure and capped at MaxDelay.
+func (p LoginPolicy) Delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// RetryAfterError wraps an error that the client may retry once RetryAfter has
+// elapsed.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Err.Error(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// AccountLockedError is returned by the failed login that locks an account, carrying
+// the address the owner should be notified at.
+type AccountLockedError struct {
+	Email string
+}
+
+func (e *AccountLockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
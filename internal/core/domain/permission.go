@@ -0,0 +1,18 @@
+package domain
+
+const (
+	PermissionMoviesRead  = "movies:read"
+	PermissionMoviesWrite = "movies:write"
+	PermissionAdmin       = "admin"
+)
+
+type Permissions []string
+
+func (p Permissions) Include(code string) bool {
+	for _, permission := range p {
+		if permission == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type replicaReadsKey struct{}
+
+// WithReplicaReads marks ctx as tolerating reads served by a database read replica,
+// which may lag a few seconds behind the primary. Only requests that never write, and
+// never act on what they read, should be marked.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+func ReplicaReadsAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(replicaReadsKey{}).(bool)
+	return allowed
+}
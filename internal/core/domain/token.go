@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"time"
+)
+
+const (
+	ScopeAuthentication = "authentication"
+	ScopeEmailChange    = "email_change"
+	ScopePasswordReset  = "password_reset"
+	ScopeTwoFactor      = "two_factor"
+)
+
+type Token struct {
+	Plaintext string    `json:"token"`
+	Hash      []byte    `json:"-"`
+	UserID    int64     `json:"-"`
+	Expiry    time.Time `json:"expiry"`
+	Scope     string    `json:"-"`
+}
+
+// GenerateToken creates a token with a random 26 character plaintext for the given
+// user and scope. Only the SHA-256 hash of the plaintext is meant to be persisted.
+func GenerateToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
+	token := &Token{
+		UserID: userID,
+		Expiry: time.Now().Add(ttl),
+		Scope:  scope,
+	}
+
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	token.Hash = HashToken(token.Plaintext)
+	return token, nil
+}
+
+// HashToken returns the SHA-256 hash of a plaintext token, as stored in the database.
+func HashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+func ValidateTokenPlaintext(plaintext string) error {
+	if len(plaintext) != 26 {
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -13,15 +13,22 @@ type Password struct {
 }
 
 type User struct {
-	ID        int64     `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  Password  `json:"-"`
-	Activated bool      `json:"activated"`
-	Version   int       `json:"-"`
+	ID                  int64      `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email"`
+	PendingEmail        *string    `json:"pending_email,omitempty"`
+	Password            Password   `json:"-" db:"-"`
+	Activated           bool       `json:"activated"`
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	DeactivatedAt       *time.Time `json:"deactivated_at,omitempty"`
+	Version             int        `json:"-"`
 }
 
+// AnonymousUser represents a request made without a valid authentication token.
+var AnonymousUser = &User{}
+
 // The Set() method calculates the bcrypt hash of a plaintext password, and stores both
 // the hash and the plaintext versions in the struct.
 func (p *Password) Set(plaintextPassword string) error {
@@ -58,10 +65,25 @@ func (u *User) PasswordMatches(plaintextPassword string) (bool, error) {
 	return match, nil
 }
 
+func (u *User) IsAnonymous() bool {
+	return u == AnonymousUser
+}
+
+// IsLocked reports whether the account is locked out after too many failed logins.
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(time.Now())
+}
+
 func (u *User) IsActivated() bool {
 	return u.Activated
 }
 
+// IsDeactivated reports whether an admin turned the account off. Accounts that were
+// never activated are not deactivated.
+func (u *User) IsDeactivated() bool {
+	return u.DeactivatedAt != nil
+}
+
 func (u *User) ValidatePasswordPlaintext(password string) error {
 	if password == "" {
 		return errors.New("password must be provided")
@@ -85,6 +107,9 @@ func (u *User) ValidateUser() error {
 		err = errors.New("email must be provided")
 		return err
 	}
-	err = u.ValidatePasswordPlaintext(*u.Password.Plaintext)
+	// The plaintext is only present when the password is being set or changed.
+	if u.Password.Plaintext != nil {
+		err = u.ValidatePasswordPlaintext(*u.Password.Plaintext)
+	}
 	return err
 }
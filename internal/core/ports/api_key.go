@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type APIKeyRepository interface {
+	Insert(ctx context.Context, key *domain.APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	GetAllForUser(ctx context.Context, userID int64) ([]*domain.APIKey, error)
+	Revoke(ctx context.Context, id, userID int64) error
+	TouchLastUsed(ctx context.Context, id int64) error
+}
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, user *domain.User, name string, permissions domain.Permissions, expiry *time.Time) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID int64) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id int64) error
+	Authenticate(ctx context.Context, plaintext string) (*domain.User, *domain.APIKey, error)
+}
@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type AuditRepository interface {
+	Insert(ctx context.Context, event *domain.AuditEvent) error
+	GetAll(ctx context.Context, auditFilter domain.AuditFilter, filter domain.Filter) ([]*domain.AuditEvent, domain.Metadata, error)
+}
+
+type AuditService interface {
+	Record(ctx context.Context, action, targetType, targetID string, metadata map[string]any)
+	GetAllEvents(ctx context.Context, auditFilter domain.AuditFilter, filter domain.Filter) ([]*domain.AuditEvent, domain.Metadata, error)
+}
@@ -5,3 +5,8 @@ import "github.com/thaian1234/green_light/internal/core/domain"
 type HealthService interface {
 	GetStatus() domain.HealthStatus
 }
+
+// ReplicaMonitor reports the state of the database read replicas.
+type ReplicaMonitor interface {
+	ReplicaStatus() []domain.ReplicaStatus
+}
@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+// IdentityProvider is an external OpenID Connect issuer users can sign in with.
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state *domain.OIDCLoginState) (string, error)
+	Exchange(ctx context.Context, code string, state *domain.OIDCLoginState) (*domain.ExternalIdentity, error)
+}
+
+type IdentityRepository interface {
+	Get(ctx context.Context, issuer, subject string) (*domain.Identity, error)
+	Insert(ctx context.Context, identity *domain.Identity) error
+	InsertState(ctx context.Context, state *domain.OIDCLoginState) error
+	TakeState(ctx context.Context, state string) (*domain.OIDCLoginState, error)
+}
+
+type OIDCService interface {
+	BeginLogin(ctx context.Context) (string, error)
+	CompleteLogin(ctx context.Context, state, code, ip string) (*domain.Token, error)
+}
@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type LoginAttemptRepository interface {
+	RecordFailure(ctx context.Context, ip string) error
+	GetFailures(ctx context.Context, ip string, since time.Time) (int, time.Time, error)
+	PruneFailures(ctx context.Context, before time.Time) (int64, error)
+}
+
+type LoginAttemptService interface {
+	Check(ctx context.Context, ip string) error
+	RecordFailure(ctx context.Context, ip string, user *domain.User) (bool, error)
+	RecordSuccess(ctx context.Context, user *domain.User) error
+	PruneFailures(ctx context.Context) (int64, error)
+}
@@ -1,5 +1,7 @@
 package ports
 
+import "context"
+
 type MailerService interface {
-	Send(recipient, templateFile string, data any) error
+	Send(ctx context.Context, recipient, templateFile string, data any) error
 }
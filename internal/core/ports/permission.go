@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type PermissionRepository interface {
+	GetAllForUser(ctx context.Context, userID int64) (domain.Permissions, error)
+	AddForUser(ctx context.Context, userID int64, codes ...string) error
+	RemoveForUser(ctx context.Context, userID int64, codes ...string) error
+}
+
+type PermissionService interface {
+	GetPermissionsForUser(ctx context.Context, userID int64) (domain.Permissions, error)
+	GrantPermissions(ctx context.Context, userID int64, codes ...string) error
+	RevokePermissions(ctx context.Context, userID int64, codes ...string) error
+}
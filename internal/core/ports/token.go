@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type TokenRepository interface {
+	Insert(ctx context.Context, token *domain.Token) error
+	DeleteAllForUser(ctx context.Context, scope string, userID int64) error
+}
+
+type TokenService interface {
+	CreateAuthenticationToken(ctx context.Context, email, password, ip string) (*domain.Token, error)
+	CompleteTwoFactorLogin(ctx context.Context, plaintext, code, ip string) (*domain.Token, error)
+	CreateExternalAuthenticationToken(ctx context.Context, user *domain.User, ip string) (*domain.Token, error)
+	NewToken(ctx context.Context, userID int64, scope string) (*domain.Token, error)
+	DeleteAllForUser(ctx context.Context, scope string, userID int64) error
+}
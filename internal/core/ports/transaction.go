@@ -0,0 +1,14 @@
+package ports
+
+import "context"
+
+// TxManager runs a unit of work atomically. Repositories called with the context
+// passed to fn take part in the transaction, and a nested WithinTx runs in a savepoint
+// so its failure only undoes its own work. fn may be run more than once when the
+// transaction hits a serialization failure, so it must not have side effects outside
+// the database and must be idempotent with respect to its arguments: structs that a
+// failed attempt modified, such as a user whose version Update bumped, have to be
+// restored or loaded again at the start of each run.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
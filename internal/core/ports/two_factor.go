@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+)
+
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID int64) (*domain.TwoFactor, error)
+	Upsert(ctx context.Context, twoFactor *domain.TwoFactor) error
+	Enable(ctx context.Context, userID int64) error
+	MarkStepUsed(ctx context.Context, userID, step int64) (bool, error)
+	Delete(ctx context.Context, userID int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes [][]byte) error
+	UseRecoveryCode(ctx context.Context, userID int64, hash []byte) (bool, error)
+}
+
+type TwoFactorService interface {
+	BeginEnrolment(ctx context.Context, user *domain.User) (*domain.TwoFactorEnrolment, error)
+	ConfirmEnrolment(ctx context.Context, user *domain.User, code string) ([]string, error)
+	Disable(ctx context.Context, user *domain.User, code string) error
+	IsEnabled(ctx context.Context, userID int64) (bool, error)
+	Verify(ctx context.Context, userID int64, code string) (bool, error)
+}
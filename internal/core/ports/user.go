@@ -2,20 +2,35 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/thaian1234/green_light/internal/core/domain"
 )
 
 type UserRepository interface {
 	Insert(ctx context.Context, user *domain.User) error
+	GetByID(ctx context.Context, id int64) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetAll(ctx context.Context, search string, filter domain.Filter) ([]*domain.User, domain.Metadata, error)
+	GetForToken(ctx context.Context, scope, plaintext string) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
+	ConfirmPendingEmail(ctx context.Context, user *domain.User) error
+	IncrementFailedLogins(ctx context.Context, id int64) (int, error)
+	SetLockedUntil(ctx context.Context, id int64, until *time.Time) error
 	Delete(ctx context.Context, id int64) error
 }
 
 type UserService interface {
 	CreateUser(ctx context.Context, user *domain.User) error
+	GetUserByID(ctx context.Context, id int64) (*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetAllUsers(ctx context.Context, search string, filter domain.Filter) ([]*domain.User, domain.Metadata, error)
+	GetUserForToken(ctx context.Context, scope, plaintext string) (*domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) error
 	DeleteUser(ctx context.Context, id int64) error
+	RequestEmailChange(ctx context.Context, user *domain.User, newEmail string) (*domain.Token, error)
+	ConfirmEmailChange(ctx context.Context, plaintext string) (*domain.User, error)
+	SetUserActivated(ctx context.Context, user *domain.User, activated bool) error
+	CreatePasswordResetToken(ctx context.Context, user *domain.User) (*domain.Token, error)
+	ResetPassword(ctx context.Context, plaintext, password string) (*domain.User, error)
 }
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"strconv"
+	"time"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/tracing"
+)
+
+type APIKeyService struct {
+	apiKeyRepo     ports.APIKeyRepository
+	userRepo       ports.UserRepository
+	permissionRepo ports.PermissionRepository
+	auditSvc       ports.AuditService
+}
+
+func NewAPIKeyService(
+	apiKeyRepo ports.APIKeyRepository,
+	userRepo ports.UserRepository,
+	permissionRepo ports.PermissionRepository,
+	auditSvc ports.AuditService,
+) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:     apiKeyRepo,
+		userRepo:       userRepo,
+		permissionRepo: permissionRepo,
+		auditSvc:       auditSvc,
+	}
+}
+
+// CreateAPIKey issues a key for user limited to permissions, which must be a subset of
+// the user's own permissions. The returned key is the only time its plaintext is
+// available.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, user *domain.User, name string, permissions domain.Permissions, expiry *time.Time) (*domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.CreateAPIKey")
+	defer span.End()
+
+	if expiry != nil && !expiry.After(time.Now()) {
+		return nil, domain.ErrorValidation
+	}
+	owned, err := s.permissionRepo.GetAllForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		if !owned.Include(permission) {
+			return nil, domain.ErrForbidden
+		}
+	}
+
+	key, err := domain.GenerateAPIKey(user.ID, name, permissions, expiry)
+	if err != nil {
+		return nil, domain.ErrTokenCreation
+	}
+	if err = s.apiKeyRepo.Insert(ctx, key); err != nil {
+		return nil, err
+	}
+	s.auditSvc.Record(ctx, domain.AuditAPIKeyCreated, domain.AuditTargetAPIKey, strconv.FormatInt(key.ID, 10), map[string]any{
+		"prefix":      key.Prefix,
+		"permissions": key.Permissions,
+	})
+	return key, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID int64) ([]*domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.ListAPIKeys")
+	defer span.End()
+
+	return s.apiKeyRepo.GetAllForUser(ctx, userID)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, id int64) error {
+	ctx, span := tracing.Start(ctx, "APIKeyService.RevokeAPIKey")
+	defer span.End()
+
+	if err := s.apiKeyRepo.Revoke(ctx, id, userID); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditAPIKeyRevoked, domain.AuditTargetAPIKey, strconv.FormatInt(id, 10), nil)
+	return nil
+}
+
+// Authenticate resolves a plaintext key to its owner. Unknown, revoked and expired
+// keys are all reported as ErrInvalidToken.
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (*domain.User, *domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer span.End()
+
+	prefix, err := domain.ParseAPIKeyPrefix(plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := s.apiKeyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if err == domain.ErrDataNotFound {
+			return nil, nil, domain.ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare(key.Hash, domain.HashToken(plaintext)) != 1 || !key.IsActive() {
+		return nil, nil, domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if err == domain.ErrDataNotFound {
+			return nil, nil, domain.ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+	if user.IsDeactivated() {
+		return nil, nil, domain.ErrInactiveAccount
+	}
+	if err = s.apiKeyRepo.TouchLastUsed(ctx, key.ID); err != nil {
+		return nil, nil, err
+	}
+	return user, key, nil
+}
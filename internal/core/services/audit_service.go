@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/tracing"
+)
+
+type AuditService struct {
+	auditRepo ports.AuditRepository
+	txManager ports.TxManager
+}
+
+func NewAuditService(auditRepo ports.AuditRepository, txManager ports.TxManager) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		txManager: txManager,
+	}
+}
+
+// Record writes an audit event attributed to the actor carried in ctx. A failure to
+// write the event is logged but never fails the action being audited; inside a
+// transaction the insert runs in a savepoint so its failure does not abort the
+// surrounding work.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string, metadata map[string]any) {
+	ctx, span := tracing.Start(ctx, "AuditService.Record")
+	defer span.End()
+
+	actor := domain.AuditActorFromContext(ctx)
+	event := &domain.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         actor.IP,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Metadata:   metadata,
+	}
+	if actor.UserID != 0 {
+		event.ActorID = &actor.UserID
+	}
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.auditRepo.Insert(ctx, event)
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record audit event", "action", action, "target_id", targetID, "err", err)
+	}
+}
+
+func (s *AuditService) GetAllEvents(ctx context.Context, auditFilter domain.AuditFilter, filter domain.Filter) ([]*domain.AuditEvent, domain.Metadata, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetAllEvents")
+	defer span.End()
+
+	return s.auditRepo.GetAll(ctx, auditFilter, filter)
+}
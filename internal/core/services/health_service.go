@@ -3,22 +3,34 @@ package services
 import (
 	"github.com/thaian1234/green_light/config"
 	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
 )
 
 type HealthService struct {
-	cfg *config.Config
+	cfg      *config.Config
+	replicas ports.ReplicaMonitor
 }
 
-func NewHealthService(cfg *config.Config) *HealthService {
+func NewHealthService(cfg *config.Config, replicas ports.ReplicaMonitor) *HealthService {
 	return &HealthService{
-		cfg: cfg,
+		cfg:      cfg,
+		replicas: replicas,
 	}
 }
 
+// GetStatus reports the service as degraded while any read replica is out of
+// rotation. Reads then fall back to the primary, so the service keeps working.
 func (s *HealthService) GetStatus() domain.HealthStatus {
-	return domain.HealthStatus{
+	status := domain.HealthStatus{
 		Status:      "available",
 		Environment: s.cfg.App.Env,
 		Version:     s.cfg.App.Version,
+		Replicas:    s.replicas.ReplicaStatus(),
 	}
+	for _, replica := range status.Replicas {
+		if !replica.Healthy {
+			status.Status = "degraded"
+		}
+	}
+	return status
 }
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/tracing"
+)
+
+type LoginAttemptService struct {
+	attemptRepo ports.LoginAttemptRepository
+	userRepo    ports.UserRepository
+	auditSvc    ports.AuditService
+	policy      domain.LoginPolicy
+}
+
+func NewLoginAttemptService(
+	cfg *config.Login,
+	attemptRepo ports.LoginAttemptRepository,
+	userRepo ports.UserRepository,
+	auditSvc ports.AuditService,
+) *LoginAttemptService {
+	policy := domain.LoginPolicy{
+		MaxAccountAttempts: cfg.MaxAccountAttempts,
+		MaxIPAttempts:      cfg.MaxIPAttempts,
+		Window:             cfg.Window,
+		LockoutDuration:    cfg.LockoutDuration,
+		BaseDelay:          cfg.BaseDelay,
+		MaxDelay:           cfg.MaxDelay,
+	}
+	return &LoginAttemptService{
+		attemptRepo: attemptRepo,
+		userRepo:    userRepo,
+		auditSvc:    auditSvc,
+		policy:      policy,
+	}
+}
+
+// Check rejects a login from ip while it is inside its progressive delay or over the
+// per-IP threshold. It runs before the password is compared so that throttled clients
+// cannot make the server spend bcrypt time.
+func (s *LoginAttemptService) Check(ctx context.Context, ip string) error {
+	ctx, span := tracing.Start(ctx, "LoginAttemptService.Check")
+	defer span.End()
+
+	now := time.Now()
+	failures, last, err := s.attemptRepo.GetFailures(ctx, ip, now.Add(-s.policy.Window))
+	if err != nil {
+		return err
+	}
+
+	wait := s.policy.Delay(failures)
+	if failures >= s.policy.MaxIPAttempts {
+		wait = s.policy.LockoutDuration
+	}
+	if retryAfter := last.Add(wait).Sub(now); retryAfter > 0 {
+		return &domain.RetryAfterError{Err: domain.ErrTooManyAttempts, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login against ip and, when the email belongs to an
+// account, against that account. It reports true when this failure locked the account.
+// Failures against an account that is already locked are counted but neither extend
+// the lock nor report it again, so nobody can keep an account locked for good or flood
+// its owner with lockout emails.
+func (s *LoginAttemptService) RecordFailure(ctx context.Context, ip string, user *domain.User) (bool, error) {
+	ctx, span := tracing.Start(ctx, "LoginAttemptService.RecordFailure")
+	defer span.End()
+
+	if err := s.attemptRepo.RecordFailure(ctx, ip); err != nil {
+		return false, err
+	}
+	if user == nil {
+		s.auditSvc.Record(ctx, domain.AuditLoginFailed, "", "", nil)
+		return false, nil
+	}
+	userID := strconv.FormatInt(user.ID, 10)
+	s.auditSvc.Record(ctx, domain.AuditLoginFailed, domain.AuditTargetUser, userID, nil)
+
+	attempts, err := s.userRepo.IncrementFailedLogins(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if user.IsLocked() || attempts < s.policy.MaxAccountAttempts {
+		return false, nil
+	}
+	lockedUntil := time.Now().Add(s.policy.LockoutDuration)
+	if err = s.userRepo.SetLockedUntil(ctx, user.ID, &lockedUntil); err != nil {
+		return false, err
+	}
+	user.LockedUntil = &lockedUntil
+	s.auditSvc.Record(ctx, domain.AuditAccountLocked, domain.AuditTargetUser, userID, map[string]any{
+		"locked_until": lockedUntil,
+	})
+	return true, nil
+}
+
+// RecordSuccess clears the account's failure counter and any expired lock.
+func (s *LoginAttemptService) RecordSuccess(ctx context.Context, user *domain.User) error {
+	ctx, span := tracing.Start(ctx, "LoginAttemptService.RecordSuccess")
+	defer span.End()
+
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return nil
+	}
+	return s.userRepo.SetLockedUntil(ctx, user.ID, nil)
+}
+
+// PruneFailures deletes the failed logins that have left the counting window. Check
+// ignores them already, so pruning runs periodically instead of on the login path.
+func (s *LoginAttemptService) PruneFailures(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "LoginAttemptService.PruneFailures")
+	defer span.End()
+
+	return s.attemptRepo.PruneFailures(ctx, time.Now().Add(-s.policy.Window))
+}
@@ -0,0 +1,68 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/internal/core/services"
+)
+
+type fakeLoginAttemptRepository struct {
+	ports.LoginAttemptRepository
+}
+
+func (fakeLoginAttemptRepository) RecordFailure(ctx context.Context, ip string) error {
+	return nil
+}
+
+// lockoutUserRepository counts failed logins and locks like the Postgres repository.
+type lockoutUserRepository struct {
+	ports.UserRepository
+	attempts int
+	locks    int
+}
+
+func (r *lockoutUserRepository) IncrementFailedLogins(ctx context.Context, id int64) (int, error) {
+	r.attempts++
+	return r.attempts, nil
+}
+
+func (r *lockoutUserRepository) SetLockedUntil(ctx context.Context, id int64, until *time.Time) error {
+	r.attempts = 0
+	r.locks++
+	return nil
+}
+
+func TestRecordFailureLocksOnce(t *testing.T) {
+	users := &lockoutUserRepository{}
+	svc := services.NewLoginAttemptService(&config.Login{
+		MaxAccountAttempts: 3,
+		MaxIPAttempts:      100,
+		Window:             time.Hour,
+		LockoutDuration:    time.Hour,
+		BaseDelay:          time.Second,
+		MaxDelay:           time.Minute,
+	}, fakeLoginAttemptRepository{}, users, fakeAudit{})
+	user := &domain.User{ID: 1, Email: "mia@example.com"}
+
+	var lockedAt []int
+	for i := 1; i <= 10; i++ {
+		locked, err := svc.RecordFailure(context.Background(), "192.0.2.1", user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locked {
+			lockedAt = append(lockedAt, i)
+		}
+	}
+	if len(lockedAt) != 1 || lockedAt[0] != 3 || users.locks != 1 {
+		t.Errorf("locked at failures %v with %d locks, want locked once at the 3rd failure", lockedAt, users.locks)
+	}
+	if lockedUntil := user.LockedUntil; lockedUntil == nil || time.Until(*lockedUntil) > time.Hour {
+		t.Errorf("locked until %v, want within the lockout duration", lockedUntil)
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"html/template"
 	"time"
@@ -9,6 +10,11 @@ import (
 	"github.com/go-mail/mail/v2"
 	"github.com/thaian1234/green_light/config"
 	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/pkg/metrics"
+	"github.com/thaian1234/green_light/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:embed templates/*
@@ -30,7 +36,10 @@ func NewMailerService(cfg *config.SMTP) *MailerService {
 	}
 }
 
-func (m *MailerService) Send(recipient, templateFile string, data any) error {
+func (m *MailerService) Send(ctx context.Context, recipient, templateFile string, data any) error {
+	_, span := tracing.Start(ctx, "MailerService.Send", trace.WithAttributes(attribute.String("mail.template", templateFile)))
+	defer span.End()
+
 	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
 	if err != nil {
 		return err
@@ -59,9 +68,13 @@ func (m *MailerService) Send(recipient, templateFile string, data any) error {
 	for i := 0; i < 3; i++ {
 		err = m.mailer.Dialer.DialAndSend(msg)
 		if nil == err {
+			metrics.MailsSent.WithLabelValues(templateFile, "success").Inc()
 			return nil
 		}
 		time.Sleep(time.Millisecond * 500)
 	}
-	return nil
+	metrics.MailsSent.WithLabelValues(templateFile, "failure").Inc()
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "failed to send mail")
+	return err
 }
@@ -2,26 +2,40 @@ package services
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/thaian1234/green_light/internal/core/domain"
 	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/tracing"
 )
 
 type MovieService struct {
 	movieRepo ports.MovieRepository
+	auditSvc  ports.AuditService
 }
 
-func NewMovieService(movieRepo ports.MovieRepository) *MovieService {
+func NewMovieService(movieRepo ports.MovieRepository, auditSvc ports.AuditService) *MovieService {
 	return &MovieService{
 		movieRepo: movieRepo,
+		auditSvc:  auditSvc,
 	}
 }
 
 func (s *MovieService) CreateMovie(ctx context.Context, movie *domain.Movie) error {
-	return s.movieRepo.Insert(ctx, movie)
+	ctx, span := tracing.Start(ctx, "MovieService.CreateMovie")
+	defer span.End()
+
+	if err := s.movieRepo.Insert(ctx, movie); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditMovieCreated, domain.AuditTargetMovie, strconv.FormatInt(movie.ID, 10), nil)
+	return nil
 }
 
 func (s *MovieService) GetMovieByID(ctx context.Context, id int64) (*domain.Movie, error) {
+	ctx, span := tracing.Start(ctx, "MovieService.GetMovieByID")
+	defer span.End()
+
 	movie, err := s.movieRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -30,13 +44,32 @@ func (s *MovieService) GetMovieByID(ctx context.Context, id int64) (*domain.Movi
 }
 
 func (s *MovieService) GetAllMovie(ctx context.Context, title string, genres []string, filter domain.Filter) ([]*domain.Movie, domain.Metadata, error) {
+	ctx, span := tracing.Start(ctx, "MovieService.GetAllMovie")
+	defer span.End()
+
 	return s.movieRepo.GetAll(ctx, title, genres, filter)
 }
 
 func (s *MovieService) UpdateMovie(ctx context.Context, movie *domain.Movie) error {
-	return s.movieRepo.Update(ctx, movie)
+	ctx, span := tracing.Start(ctx, "MovieService.UpdateMovie")
+	defer span.End()
+
+	if err := s.movieRepo.Update(ctx, movie); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditMovieUpdated, domain.AuditTargetMovie, strconv.FormatInt(movie.ID, 10), map[string]any{
+		"version": movie.Version,
+	})
+	return nil
 }
 
 func (s *MovieService) DeleteMovie(ctx context.Context, id int64) error {
-	return s.movieRepo.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "MovieService.DeleteMovie")
+	defer span.End()
+
+	if err := s.movieRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditMovieDeleted, domain.AuditTargetMovie, strconv.FormatInt(id, 10), nil)
+	return nil
 }
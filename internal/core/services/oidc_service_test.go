@@ -0,0 +1,526 @@
+package services_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/adapter/oidc"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/internal/core/services"
+	"github.com/thaian1234/green_light/pkg/logger"
+)
+
+const testClientID = "green-light"
+
+func TestMain(m *testing.M) {
+	if err := logger.Initialize(&config.Logger{LogLevel: "fatal", Outputs: []string{"stdout"}}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeIssuer is an OpenID Connect issuer serving discovery, JWKS and the token
+// endpoint. Authorization codes are handed out by authorize, standing in for the
+// user's trip through the provider's login page.
+type fakeIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]issuedCode
+}
+
+type issuedCode struct {
+	challenge string
+	claims    map[string]any
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &fakeIssuer{key: key, codes: map[string]issuedCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                                issuer.URL,
+			"authorization_endpoint":                issuer.URL + "/authorize",
+			"token_endpoint":                        issuer.URL + "/token",
+			"jwks_uri":                              issuer.URL + "/keys",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"keys": []map[string]any{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", issuer.token)
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// token redeems an authorization code once, and only with the PKCE verifier matching
+// the challenge it was issued for.
+func (f *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	f.mu.Lock()
+	code, ok := f.codes[r.PostForm.Get("code")]
+	delete(f.codes, r.PostForm.Get("code"))
+	f.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     f.sign(code.claims),
+	})
+}
+
+// authorize plays the user signing in at the provider: it checks the authorization
+// URL carries a state, nonce and S256 PKCE challenge and issues a code whose ID token
+// has claims, plus the nonce unless claims sets one. It returns the state and code
+// the provider would redirect back with.
+func (f *fakeIssuer) authorize(t *testing.T, authURL string, claims map[string]any) (string, string) {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if !strings.HasPrefix(authURL, f.URL+"/authorize") {
+		t.Fatalf("auth URL %q does not point at the issuer", authURL)
+	}
+	if query.Get("client_id") != testClientID {
+		t.Fatalf("client_id = %q, want %q", query.Get("client_id"), testClientID)
+	}
+	if query.Get("state") == "" || query.Get("nonce") == "" {
+		t.Fatalf("auth URL %q has no state or nonce", authURL)
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("auth URL %q has no S256 code challenge", authURL)
+	}
+
+	idClaims := map[string]any{
+		"iss":   f.URL,
+		"aud":   testClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for name, value := range claims {
+		idClaims[name] = value
+	}
+
+	code := base64.RawURLEncoding.EncodeToString([]byte(query.Get("state")))
+	f.mu.Lock()
+	f.codes[code] = issuedCode{challenge: query.Get("code_challenge"), claims: idClaims}
+	f.mu.Unlock()
+	return query.Get("state"), code
+}
+
+func (f *fakeIssuer) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type oidcTest struct {
+	issuer     *fakeIssuer
+	svc        *services.OIDCService
+	identities *fakeIdentityRepository
+	users      *fakeUserRepository
+	tokens     *fakeTokenRepository
+	attempts   *fakeLoginAttempts
+	twoFactor  *fakeTwoFactor
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+	issuer := newFakeIssuer(t)
+	test := &oidcTest{
+		issuer:     issuer,
+		identities: &fakeIdentityRepository{identities: map[string]*domain.Identity{}, states: map[string]*domain.OIDCLoginState{}},
+		users:      &fakeUserRepository{users: map[int64]*domain.User{}},
+		tokens:     &fakeTokenRepository{},
+		attempts:   &fakeLoginAttempts{},
+		twoFactor:  &fakeTwoFactor{enabled: map[int64]bool{}},
+	}
+	audit := fakeAudit{}
+	tokenSvc, err := services.NewTokenService(&config.Token{Duration: time.Hour}, test.tokens, test.users, test.attempts, test.twoFactor, audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := oidc.NewAdapter(&config.OIDC{
+		Enabled:     true,
+		Issuer:      issuer.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/v1/oidc/callback",
+	})
+	test.svc = services.NewOIDCService(provider, test.identities, test.users, tokenSvc, audit)
+	return test
+}
+
+// login runs a full sign-in whose ID token carries claims.
+func (o *oidcTest) login(t *testing.T, claims map[string]any) (*domain.Token, error) {
+	t.Helper()
+	authURL, err := o.svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := o.issuer.authorize(t, authURL, claims)
+	return o.svc.CompleteLogin(context.Background(), state, code, "192.0.2.1")
+}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	o := newOIDCTest(t)
+
+	token, err := o.login(t, map[string]any{"sub": "alice-1", "email": "alice@example.com", "email_verified": true, "name": "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Scope != domain.ScopeAuthentication {
+		t.Errorf("scope = %q, want %q", token.Scope, domain.ScopeAuthentication)
+	}
+	user, err := o.users.GetByEmail(context.Background(), "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Activated || user.Name != "Alice" || token.UserID != user.ID {
+		t.Errorf("created user = %+v for token of user %d", user, token.UserID)
+	}
+	identity, err := o.identities.Get(context.Background(), o.issuer.URL, "alice-1")
+	if err != nil || identity.UserID != user.ID {
+		t.Errorf("identity = %+v, %v; want it linked to user %d", identity, err, user.ID)
+	}
+}
+
+func TestOIDCLoginLinksExistingUser(t *testing.T) {
+	o := newOIDCTest(t)
+	existing := o.users.add(&domain.User{Name: "Bob", Email: "bob@example.com", Activated: true})
+
+	token, err := o.login(t, map[string]any{"sub": "bob-1", "email": "bob@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.UserID != existing.ID || len(o.users.users) != 1 {
+		t.Errorf("token for user %d with %d users, want the existing user %d", token.UserID, len(o.users.users), existing.ID)
+	}
+
+	// Once linked, the identity signs in its user even after the email changes.
+	token, err = o.login(t, map[string]any{"sub": "bob-1", "email": "bob@elsewhere.example", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.UserID != existing.ID {
+		t.Errorf("token for user %d, want %d", token.UserID, existing.ID)
+	}
+}
+
+func TestOIDCLoginLinksUnactivatedUser(t *testing.T) {
+	o := newOIDCTest(t)
+	existing := o.users.add(&domain.User{Name: "Carol", Email: "carol@example.com"})
+	if err := existing.Password.Set("chosen-at-registration"); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := o.login(t, map[string]any{"sub": "carol-1", "email": "carol@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.UserID != existing.ID || existing.Activated || existing.Version != 0 {
+		t.Errorf("token for user %d, activated %v, version %d; want user %d left as it was", token.UserID, existing.Activated, existing.Version, existing.ID)
+	}
+	if match, _ := existing.PasswordMatches("chosen-at-registration"); !match {
+		t.Error("the password chosen at registration was replaced")
+	}
+	if o.tokens.deleted(domain.ScopeAuthentication, existing.ID) {
+		t.Error("authentication tokens of the linked user were revoked")
+	}
+}
+
+func TestOIDCLoginRefusesDeactivatedUser(t *testing.T) {
+	o := newOIDCTest(t)
+	deactivatedAt := time.Now()
+	deactivated := o.users.add(&domain.User{Email: "kim@example.com", DeactivatedAt: &deactivatedAt})
+	linked := o.users.add(&domain.User{Email: "leo@example.com", Activated: true})
+
+	if _, err := o.login(t, map[string]any{"sub": "kim-1", "email": deactivated.Email, "email_verified": true}); err != domain.ErrInactiveAccount {
+		t.Errorf("deactivated user: err = %v, want %v", err, domain.ErrInactiveAccount)
+	}
+	if len(o.identities.identities) != 0 {
+		t.Error("a deactivated user was linked")
+	}
+
+	if _, err := o.login(t, map[string]any{"sub": "leo-1", "email": linked.Email, "email_verified": true}); err != nil {
+		t.Fatal(err)
+	}
+	linked.Activated = false
+	linked.DeactivatedAt = &deactivatedAt
+	if _, err := o.login(t, map[string]any{"sub": "leo-1"}); err != domain.ErrInactiveAccount {
+		t.Errorf("deactivated user with a linked identity: err = %v, want %v", err, domain.ErrInactiveAccount)
+	}
+}
+
+func TestOIDCLoginRejectsUnverifiedEmail(t *testing.T) {
+	o := newOIDCTest(t)
+	o.users.add(&domain.User{Name: "Dave", Email: "dave@example.com", Activated: true})
+
+	_, err := o.login(t, map[string]any{"sub": "mallory-1", "email": "dave@example.com", "email_verified": false})
+	if err != domain.ErrUnverifiedIdentity {
+		t.Fatalf("err = %v, want %v", err, domain.ErrUnverifiedIdentity)
+	}
+	if len(o.identities.identities) != 0 {
+		t.Error("an unverified email was linked")
+	}
+}
+
+func TestOIDCLoginRejectsNonceMismatch(t *testing.T) {
+	o := newOIDCTest(t)
+
+	_, err := o.login(t, map[string]any{"sub": "erin-1", "email": "erin@example.com", "email_verified": true, "nonce": "replayed"})
+	if err != domain.ErrInvalidToken {
+		t.Fatalf("err = %v, want %v", err, domain.ErrInvalidToken)
+	}
+	if len(o.users.users) != 0 {
+		t.Error("a user was created from an ID token with the wrong nonce")
+	}
+}
+
+func TestOIDCLoginState(t *testing.T) {
+	o := newOIDCTest(t)
+	claims := map[string]any{"sub": "frank-1", "email": "frank@example.com", "email_verified": true}
+
+	authURL, err := o.svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := o.issuer.authorize(t, authURL, claims)
+
+	if _, err = o.svc.CompleteLogin(context.Background(), "unknown", code, "192.0.2.1"); err != domain.ErrInvalidToken {
+		t.Errorf("unknown state: err = %v, want %v", err, domain.ErrInvalidToken)
+	}
+	if _, err = o.svc.CompleteLogin(context.Background(), state, code, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = o.svc.CompleteLogin(context.Background(), state, code, "192.0.2.1"); err != domain.ErrInvalidToken {
+		t.Errorf("reused state: err = %v, want %v", err, domain.ErrInvalidToken)
+	}
+}
+
+func TestOIDCLoginRequiresPKCEVerifier(t *testing.T) {
+	o := newOIDCTest(t)
+
+	authURL, err := o.svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := o.issuer.authorize(t, authURL, map[string]any{"sub": "grace-1", "email": "grace@example.com", "email_verified": true})
+	o.identities.states[state].CodeVerifier = "not-the-verifier-of-this-login"
+
+	if _, err = o.svc.CompleteLogin(context.Background(), state, code, "192.0.2.1"); err != domain.ErrIdentityProvider {
+		t.Fatalf("err = %v, want %v", err, domain.ErrIdentityProvider)
+	}
+}
+
+func TestOIDCLoginAppliesPasswordLoginRules(t *testing.T) {
+	o := newOIDCTest(t)
+	lockedUntil := time.Now().Add(time.Hour)
+	locked := o.users.add(&domain.User{Email: "heidi@example.com", Activated: true, LockedUntil: &lockedUntil})
+	withTwoFactor := o.users.add(&domain.User{Email: "ivan@example.com", Activated: true})
+	o.twoFactor.enabled[withTwoFactor.ID] = true
+
+	if _, err := o.login(t, map[string]any{"sub": "heidi-1", "email": locked.Email, "email_verified": true}); err != domain.ErrAccountLocked {
+		t.Errorf("locked user: err = %v, want %v", err, domain.ErrAccountLocked)
+	}
+
+	token, err := o.login(t, map[string]any{"sub": "ivan-1", "email": withTwoFactor.Email, "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Scope != domain.ScopeTwoFactor {
+		t.Errorf("two-factor user: scope = %q, want %q", token.Scope, domain.ScopeTwoFactor)
+	}
+
+	o.attempts.err = &domain.RetryAfterError{RetryAfter: time.Minute}
+	if _, err = o.login(t, map[string]any{"sub": "ivan-1"}); err != o.attempts.err {
+		t.Errorf("throttled IP: err = %v, want %v", err, o.attempts.err)
+	}
+}
+
+type fakeIdentityRepository struct {
+	identities map[string]*domain.Identity
+	states     map[string]*domain.OIDCLoginState
+}
+
+func (r *fakeIdentityRepository) Get(ctx context.Context, issuer, subject string) (*domain.Identity, error) {
+	identity, ok := r.identities[issuer+" "+subject]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+	return identity, nil
+}
+
+func (r *fakeIdentityRepository) Insert(ctx context.Context, identity *domain.Identity) error {
+	r.identities[identity.Issuer+" "+identity.Subject] = identity
+	return nil
+}
+
+func (r *fakeIdentityRepository) InsertState(ctx context.Context, state *domain.OIDCLoginState) error {
+	r.states[state.State] = state
+	return nil
+}
+
+func (r *fakeIdentityRepository) TakeState(ctx context.Context, state string) (*domain.OIDCLoginState, error) {
+	loginState, ok := r.states[state]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+	delete(r.states, state)
+	return loginState, nil
+}
+
+// fakeUserRepository keeps users in memory. Methods the OIDC flow does not use are
+// left to the nil embedded interface.
+type fakeUserRepository struct {
+	ports.UserRepository
+	users map[int64]*domain.User
+}
+
+func (r *fakeUserRepository) add(user *domain.User) *domain.User {
+	user.ID = int64(len(r.users) + 1)
+	r.users[user.ID] = user
+	return user
+}
+
+func (r *fakeUserRepository) Insert(ctx context.Context, user *domain.User) error {
+	if _, err := r.GetByEmail(ctx, user.Email); err == nil {
+		return domain.ErrDuplicatedEmail
+	}
+	r.add(user)
+	return nil
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, domain.ErrDataNotFound
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, user *domain.User) error {
+	user.Version++
+	r.users[user.ID] = user
+	return nil
+}
+
+type fakeTokenRepository struct {
+	deletions []tokenDeletion
+}
+
+type tokenDeletion struct {
+	scope  string
+	userID int64
+}
+
+func (r *fakeTokenRepository) Insert(ctx context.Context, token *domain.Token) error {
+	return nil
+}
+
+func (r *fakeTokenRepository) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
+	r.deletions = append(r.deletions, tokenDeletion{scope, userID})
+	return nil
+}
+
+func (r *fakeTokenRepository) deleted(scope string, userID int64) bool {
+	return slices.Contains(r.deletions, tokenDeletion{scope, userID})
+}
+
+type fakeLoginAttempts struct {
+	ports.LoginAttemptService
+	err error
+}
+
+func (a *fakeLoginAttempts) Check(ctx context.Context, ip string) error {
+	return a.err
+}
+
+func (a *fakeLoginAttempts) RecordSuccess(ctx context.Context, user *domain.User) error {
+	return nil
+}
+
+type fakeTwoFactor struct {
+	ports.TwoFactorService
+	enabled map[int64]bool
+}
+
+func (f *fakeTwoFactor) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	return f.enabled[userID], nil
+}
+
+type fakeAudit struct {
+	ports.AuditService
+}
+
+func (fakeAudit) Record(ctx context.Context, action, targetType, targetID string, metadata map[string]any) {
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/tracing"
+)
+
+type PermissionService struct {
+	permissionRepo ports.PermissionRepository
+	auditSvc       ports.AuditService
+}
+
+func NewPermissionService(permissionRepo ports.PermissionRepository, auditSvc ports.AuditService) *PermissionService {
+	return &PermissionService{
+		permissionRepo: permissionRepo,
+		auditSvc:       auditSvc,
+	}
+}
+
+func (s *PermissionService) GetPermissionsForUser(ctx context.Context, userID int64) (domain.Permissions, error) {
+	ctx, span := tracing.Start(ctx, "PermissionService.GetPermissionsForUser")
+	defer span.End()
+
+	return s.permissionRepo.GetAllForUser(ctx, userID)
+}
+
+func (s *PermissionService) GrantPermissions(ctx context.Context, userID int64, codes ...string) error {
+	ctx, span := tracing.Start(ctx, "PermissionService.GrantPermissions")
+	defer span.End()
+
+	if err := s.permissionRepo.AddForUser(ctx, userID, codes...); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditPermissionGranted, domain.AuditTargetUser, strconv.FormatInt(userID, 10), map[string]any{
+		"permissions": codes,
+	})
+	return nil
+}
+
+func (s *PermissionService) RevokePermissions(ctx context.Context, userID int64, codes ...string) error {
+	ctx, span := tracing.Start(ctx, "PermissionService.RevokePermissions")
+	defer span.End()
+
+	if err := s.permissionRepo.RemoveForUser(ctx, userID, codes...); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditPermissionRevoked, domain.AuditTargetUser, strconv.FormatInt(userID, 10), map[string]any{
+		"permissions": codes,
+	})
+	return nil
+}
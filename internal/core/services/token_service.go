@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/tracing"
+)
+
+type TokenService struct {
+	tokenRepo     ports.TokenRepository
+	userRepo      ports.UserRepository
+	loginAttempts ports.LoginAttemptService
+	twoFactor     ports.TwoFactorService
+	auditSvc      ports.AuditService
+	dummyPassword domain.Password
+	ttls          map[string]time.Duration
+}
+
+func NewTokenService(
+	cfg *config.Token,
+	tokenRepo ports.TokenRepository,
+	userRepo ports.UserRepository,
+	loginAttempts ports.LoginAttemptService,
+	twoFactor ports.TwoFactorService,
+	auditSvc ports.AuditService,
+) (*TokenService, error) {
+	authDuration := cfg.Duration
+	// Unknown emails are compared against this hash so that a login takes the same
+	// time whether or not the account exists.
+	var dummyPassword domain.Password
+	if err := dummyPassword.Set("greenlight-dummy-password"); err != nil {
+		return nil, err
+	}
+	return &TokenService{
+		tokenRepo:     tokenRepo,
+		userRepo:      userRepo,
+		loginAttempts: loginAttempts,
+		twoFactor:     twoFactor,
+		auditSvc:      auditSvc,
+		dummyPassword: dummyPassword,
+		ttls: map[string]time.Duration{
+			domain.ScopeAuthentication: authDuration,
+			domain.ScopeEmailChange:    24 * time.Hour,
+			domain.ScopePasswordReset:  45 * time.Minute,
+			domain.ScopeTwoFactor:      5 * time.Minute,
+		},
+	}, nil
+}
+
+// CreateAuthenticationToken logs the user in from ip. Unknown emails, wrong passwords
+// and locked accounts all fail with ErrInvalidCredentials so the response never tells
+// whether an account exists. The one failure that locks an account returns an
+// AccountLockedError instead, so the caller can notify the owner.
+//
+// For users with two-factor authentication enabled the returned token has the
+// two_factor scope and must be exchanged through CompleteTwoFactorLogin.
+func (s *TokenService) CreateAuthenticationToken(ctx context.Context, email, password, ip string) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.CreateAuthenticationToken")
+	defer span.End()
+
+	if err := s.loginAttempts.Check(ctx, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil && err != domain.ErrDataNotFound {
+		return nil, err
+	}
+
+	var match bool
+	if user != nil {
+		match, err = user.PasswordMatches(password)
+	} else {
+		_, err = s.dummyPassword.Matches(password)
+	}
+	if err != nil {
+		return nil, domain.ErrInternalServer
+	}
+
+	if !match || user.IsLocked() {
+		locked, err := s.loginAttempts.RecordFailure(ctx, ip, user)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			return nil, &domain.AccountLockedError{Email: user.Email}
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	return s.issueLoginToken(ctx, user)
+}
+
+// CreateExternalAuthenticationToken logs in from ip a user whose identity has been
+// vouched for by an external identity provider. It applies the rules of a password
+// login: throttled IPs and locked accounts are rejected, and users with two-factor
+// authentication enabled get a two_factor token.
+func (s *TokenService) CreateExternalAuthenticationToken(ctx context.Context, user *domain.User, ip string) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.CreateExternalAuthenticationToken")
+	defer span.End()
+
+	if err := s.loginAttempts.Check(ctx, ip); err != nil {
+		return nil, err
+	}
+	if user.IsLocked() {
+		return nil, domain.ErrAccountLocked
+	}
+	return s.issueLoginToken(ctx, user)
+}
+
+// issueLoginToken issues the token for a user who has just proven their identity: a
+// two_factor token when two-factor authentication is enabled, and an authentication
+// token otherwise. Accounts deactivated by an admin are refused.
+func (s *TokenService) issueLoginToken(ctx context.Context, user *domain.User) (*domain.Token, error) {
+	if user.IsDeactivated() {
+		return nil, domain.ErrInactiveAccount
+	}
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		if err = s.tokenRepo.DeleteAllForUser(ctx, domain.ScopeTwoFactor, user.ID); err != nil {
+			return nil, err
+		}
+		return s.NewToken(ctx, user.ID, domain.ScopeTwoFactor)
+	}
+
+	if err = s.loginAttempts.RecordSuccess(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.NewToken(ctx, user.ID, domain.ScopeAuthentication)
+}
+
+// CompleteTwoFactorLogin exchanges a two_factor token and a TOTP or recovery code for
+// an authentication token. Wrong codes count as failed logins.
+func (s *TokenService) CompleteTwoFactorLogin(ctx context.Context, plaintext, code, ip string) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.CompleteTwoFactorLogin")
+	defer span.End()
+
+	if err := s.loginAttempts.Check(ctx, ip); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateTokenPlaintext(plaintext); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetForToken(ctx, domain.ScopeTwoFactor, plaintext)
+	if err != nil {
+		if err == domain.ErrDataNotFound {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	ok, err := s.twoFactor.Verify(ctx, user.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || user.IsLocked() {
+		locked, err := s.loginAttempts.RecordFailure(ctx, ip, user)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			return nil, &domain.AccountLockedError{Email: user.Email}
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if user.IsDeactivated() {
+		return nil, domain.ErrInactiveAccount
+	}
+	if err = s.tokenRepo.DeleteAllForUser(ctx, domain.ScopeTwoFactor, user.ID); err != nil {
+		return nil, err
+	}
+	if err = s.loginAttempts.RecordSuccess(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.NewToken(ctx, user.ID, domain.ScopeAuthentication)
+}
+
+func (s *TokenService) NewToken(ctx context.Context, userID int64, scope string) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.NewToken")
+	defer span.End()
+
+	ttl, ok := s.ttls[scope]
+	if !ok {
+		return nil, domain.ErrTokenCreation
+	}
+	token, err := domain.GenerateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, domain.ErrTokenCreation
+	}
+	if err = s.tokenRepo.Insert(ctx, token); err != nil {
+		return nil, err
+	}
+	s.auditSvc.Record(ctx, domain.AuditTokenIssued, domain.AuditTargetUser, strconv.FormatInt(userID, 10), map[string]any{
+		"scope": scope,
+	})
+	return token, nil
+}
+
+func (s *TokenService) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
+	ctx, span := tracing.Start(ctx, "TokenService.DeleteAllForUser")
+	defer span.End()
+
+	return s.tokenRepo.DeleteAllForUser(ctx, scope, userID)
+}
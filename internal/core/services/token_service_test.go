@@ -0,0 +1,38 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thaian1234/green_light/config"
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/services"
+)
+
+func TestCreateAuthenticationTokenRefusesDeactivatedUser(t *testing.T) {
+	users := &fakeUserRepository{users: map[int64]*domain.User{}}
+	tokenSvc, err := services.NewTokenService(&config.Token{Duration: time.Hour}, &fakeTokenRepository{}, users, &fakeLoginAttempts{}, &fakeTwoFactor{enabled: map[int64]bool{}}, fakeAudit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deactivatedAt := time.Now()
+	user := users.add(&domain.User{Email: "judy@example.com", DeactivatedAt: &deactivatedAt})
+	if err = user.Password.Set("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = tokenSvc.CreateAuthenticationToken(context.Background(), user.Email, "correct horse", "192.0.2.1"); err != domain.ErrInactiveAccount {
+		t.Errorf("deactivated user: err = %v, want %v", err, domain.ErrInactiveAccount)
+	}
+
+	// Accounts that were never activated can still log in.
+	user.DeactivatedAt = nil
+	token, err := tokenSvc.CreateAuthenticationToken(context.Background(), user.Email, "correct horse", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Scope != domain.ScopeAuthentication {
+		t.Errorf("scope = %q, want %q", token.Scope, domain.ScopeAuthentication)
+	}
+}
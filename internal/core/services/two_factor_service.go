@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/tracing"
+)
+
+type TwoFactorService struct {
+	twoFactorRepo ports.TwoFactorRepository
+	auditSvc      ports.AuditService
+	issuer        string
+}
+
+func NewTwoFactorService(issuer string, twoFactorRepo ports.TwoFactorRepository, auditSvc ports.AuditService) *TwoFactorService {
+	if issuer == "" {
+		issuer = "Greenlight"
+	}
+	return &TwoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		auditSvc:      auditSvc,
+		issuer:        issuer,
+	}
+}
+
+// BeginEnrolment generates a new secret for the user. Two-factor authentication is
+// not enforced until ConfirmEnrolment succeeds with a code derived from it.
+func (s *TwoFactorService) BeginEnrolment(ctx context.Context, user *domain.User) (*domain.TwoFactorEnrolment, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.BeginEnrolment")
+	defer span.End()
+
+	secret, err := domain.GenerateTOTPSecret()
+	if err != nil {
+		return nil, domain.ErrInternalServer
+	}
+	twoFactor := &domain.TwoFactor{
+		UserID: user.ID,
+		Secret: secret,
+	}
+	if err = s.twoFactorRepo.Upsert(ctx, twoFactor); err != nil {
+		return nil, err
+	}
+	return &domain.TwoFactorEnrolment{
+		Secret: domain.EncodeTOTPSecret(secret),
+		URI:    domain.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrolment enables two-factor authentication once the user proves they can
+// generate codes, and returns a fresh set of recovery codes. The plaintext codes are
+// only available here; just their hashes are stored.
+func (s *TwoFactorService) ConfirmEnrolment(ctx context.Context, user *domain.User, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.ConfirmEnrolment")
+	defer span.End()
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, domain.ErrConflictingData
+	}
+	ok, err := s.verifyTOTP(ctx, twoFactor, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	codes, err := domain.GenerateRecoveryCodes(domain.RecoveryCodeCount)
+	if err != nil {
+		return nil, domain.ErrInternalServer
+	}
+	hashes := make([][]byte, len(codes))
+	for i, recoveryCode := range codes {
+		hashes[i] = domain.HashRecoveryCode(recoveryCode)
+	}
+	if err = s.twoFactorRepo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	if err = s.twoFactorRepo.Enable(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	s.auditSvc.Record(ctx, domain.AuditTwoFactorEnabled, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+	return codes, nil
+}
+
+func (s *TwoFactorService) Disable(ctx context.Context, user *domain.User, code string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Disable")
+	defer span.End()
+
+	ok, err := s.Verify(ctx, user.ID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidCredentials
+	}
+	if err = s.twoFactorRepo.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditTwoFactorDisabled, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+	return nil
+}
+
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.IsEnabled")
+	defer span.End()
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		if err == domain.ErrDataNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+// Verify accepts either a current TOTP code or an unused recovery code for a user with
+// two-factor authentication enabled. Each code is accepted at most once.
+func (s *TwoFactorService) Verify(ctx context.Context, userID int64, code string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Verify")
+	defer span.End()
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		if err == domain.ErrDataNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if !twoFactor.Enabled {
+		return false, nil
+	}
+	if len(code) == domain.TOTPDigits {
+		return s.verifyTOTP(ctx, twoFactor, code)
+	}
+	return s.twoFactorRepo.UseRecoveryCode(ctx, userID, domain.HashRecoveryCode(code))
+}
+
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, twoFactor *domain.TwoFactor, code string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.verifyTOTP")
+	defer span.End()
+
+	step, ok := domain.ValidateTOTP(twoFactor.Secret, code, time.Now(), twoFactor.LastUsedStep)
+	if !ok {
+		return false, nil
+	}
+	return s.twoFactorRepo.MarkStepUsed(ctx, twoFactor.UserID, step)
+}
@@ -2,33 +2,265 @@ package services
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/thaian1234/green_light/internal/core/domain"
 	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/pkg/tracing"
 )
 
 type UserService struct {
 	userRepository ports.UserRepository
+	tokenService   ports.TokenService
+	auditSvc       ports.AuditService
+	txManager      ports.TxManager
 }
 
-func NewUserService(userRepository ports.UserRepository) *UserService {
+func NewUserService(userRepository ports.UserRepository, tokenService ports.TokenService, auditSvc ports.AuditService, txManager ports.TxManager) *UserService {
 	return &UserService{
 		userRepository: userRepository,
+		tokenService:   tokenService,
+		auditSvc:       auditSvc,
+		txManager:      txManager,
 	}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, user *domain.User) error {
-	return s.userRepository.Insert(ctx, user)
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	if err := s.userRepository.Insert(ctx, user); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditUserRegistered, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+	return nil
+}
+
+func (s *UserService) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	return s.userRepository.GetByID(ctx, id)
+}
+
+func (s *UserService) GetAllUsers(ctx context.Context, search string, filter domain.Filter) ([]*domain.User, domain.Metadata, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
+
+	return s.userRepository.GetAll(ctx, search, filter)
 }
 
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer span.End()
+
 	return s.userRepository.GetByEmail(ctx, email)
 }
 
+func (s *UserService) GetUserForToken(ctx context.Context, scope, plaintext string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserForToken")
+	defer span.End()
+
+	if err := domain.ValidateTokenPlaintext(plaintext); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepository.GetForToken(ctx, scope, plaintext)
+	if err != nil {
+		if err == domain.ErrDataNotFound {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser persists changes to the user. When the password was changed, every
+// authentication token issued for the user is revoked so other sessions must log in
+// again with the new password. The update and the revocation happen atomically.
 func (s *UserService) UpdateUser(ctx context.Context, user *domain.User) error {
-	return s.userRepository.Update(ctx, user)
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	return s.txManager.WithinTx(ctx, restoringUser(user, func(ctx context.Context) error {
+		if err := s.userRepository.Update(ctx, user); err != nil {
+			return err
+		}
+		passwordChanged := user.Password.Plaintext != nil
+		s.auditSvc.Record(ctx, domain.AuditUserUpdated, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), map[string]any{
+			"password_changed": passwordChanged,
+		})
+		if passwordChanged {
+			return s.tokenService.DeleteAllForUser(ctx, domain.ScopeAuthentication, user.ID)
+		}
+		return nil
+	}))
 }
 
+// DeleteUser removes the user account. Rows owned by the user, such as tokens, are
+// removed by the ON DELETE CASCADE foreign keys on their tables. Audit events are not
+// tied to users, so the deletion is recorded, and earlier events keep their actor,
+// even when users delete their own account.
 func (s *UserService) DeleteUser(ctx context.Context, id int64) error {
-	return s.userRepository.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	if err := s.userRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditSvc.Record(ctx, domain.AuditUserDeleted, domain.AuditTargetUser, strconv.FormatInt(id, 10), nil)
+	return nil
+}
+
+// RequestEmailChange stores newEmail as the user's pending email and issues a token
+// that must be presented to ConfirmEmailChange before the address is swapped. Any
+// earlier, unconfirmed request is superseded.
+func (s *UserService) RequestEmailChange(ctx context.Context, user *domain.User, newEmail string) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RequestEmailChange")
+	defer span.End()
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return nil, domain.ErrNoUpdatedData
+	}
+	_, err := s.userRepository.GetByEmail(ctx, newEmail)
+	switch {
+	case err == nil:
+		return nil, domain.ErrDuplicatedEmail
+	case err != domain.ErrDataNotFound:
+		return nil, err
+	}
+
+	user.PendingEmail = &newEmail
+	var token *domain.Token
+	err = s.txManager.WithinTx(ctx, restoringUser(user, func(ctx context.Context) error {
+		if err := s.userRepository.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := s.tokenService.DeleteAllForUser(ctx, domain.ScopeEmailChange, user.ID); err != nil {
+			return err
+		}
+		s.auditSvc.Record(ctx, domain.AuditEmailChangeRequested, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+		var err error
+		token, err = s.tokenService.NewToken(ctx, user.ID, domain.ScopeEmailChange)
+		return err
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (s *UserService) ConfirmEmailChange(ctx context.Context, plaintext string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ConfirmEmailChange")
+	defer span.End()
+
+	user, err := s.GetUserForToken(ctx, domain.ScopeEmailChange, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if user.PendingEmail == nil {
+		return nil, domain.ErrInvalidToken
+	}
+	err = s.txManager.WithinTx(ctx, restoringUser(user, func(ctx context.Context) error {
+		if err := s.userRepository.ConfirmPendingEmail(ctx, user); err != nil {
+			return err
+		}
+		if err := s.tokenService.DeleteAllForUser(ctx, domain.ScopeEmailChange, user.ID); err != nil {
+			return err
+		}
+		s.auditSvc.Record(ctx, domain.AuditEmailChanged, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetUserActivated activates or deactivates the user. Deactivating records when the
+// account was turned off, which blocks every further login, and revokes the user's
+// authentication tokens so existing sessions end immediately.
+func (s *UserService) SetUserActivated(ctx context.Context, user *domain.User, activated bool) error {
+	ctx, span := tracing.Start(ctx, "UserService.SetUserActivated")
+	defer span.End()
+
+	user.Activated = activated
+	user.DeactivatedAt = nil
+	if !activated {
+		now := time.Now()
+		user.DeactivatedAt = &now
+	}
+	return s.txManager.WithinTx(ctx, restoringUser(user, func(ctx context.Context) error {
+		if err := s.userRepository.Update(ctx, user); err != nil {
+			return err
+		}
+		action := domain.AuditUserActivated
+		if !activated {
+			action = domain.AuditUserDeactivated
+		}
+		s.auditSvc.Record(ctx, action, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+		if !activated {
+			return s.tokenService.DeleteAllForUser(ctx, domain.ScopeAuthentication, user.ID)
+		}
+		return nil
+	}))
+}
+
+func (s *UserService) CreatePasswordResetToken(ctx context.Context, user *domain.User) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreatePasswordResetToken")
+	defer span.End()
+
+	var token *domain.Token
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tokenService.DeleteAllForUser(ctx, domain.ScopePasswordReset, user.ID); err != nil {
+			return err
+		}
+		s.auditSvc.Record(ctx, domain.AuditPasswordResetRequested, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+		var err error
+		token, err = s.tokenService.NewToken(ctx, user.ID, domain.ScopePasswordReset)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (s *UserService) ResetPassword(ctx context.Context, plaintext, password string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+
+	user, err := s.GetUserForToken(ctx, domain.ScopePasswordReset, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if err = user.Password.Set(password); err != nil {
+		return nil, domain.ErrInternalServer
+	}
+	err = s.txManager.WithinTx(ctx, restoringUser(user, func(ctx context.Context) error {
+		if err := s.UpdateUser(ctx, user); err != nil {
+			return err
+		}
+		if err := s.tokenService.DeleteAllForUser(ctx, domain.ScopePasswordReset, user.ID); err != nil {
+			return err
+		}
+		s.auditSvc.Record(ctx, domain.AuditPasswordReset, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil)
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// restoringUser wraps fn so that every run starts from the user as it was passed in.
+// WithinTx runs fn again after a serialization failure, and the writes of the failed
+// attempt, such as the version bumped by Update, must not carry over into the next.
+func restoringUser(user *domain.User, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	snapshot := *user
+	return func(ctx context.Context) error {
+		*user = snapshot
+		return fn(ctx)
+	}
 }
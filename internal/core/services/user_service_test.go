@@ -0,0 +1,69 @@
+package services_test
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/thaian1234/green_light/internal/core/domain"
+	"github.com/thaian1234/green_light/internal/core/ports"
+	"github.com/thaian1234/green_light/internal/core/services"
+)
+
+// retryingTxManager runs fn a second time after rolling back the first run, as
+// WithinTx does after a serialization failure.
+type retryingTxManager struct {
+	users *versionedUserRepository
+}
+
+func (m retryingTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	versions := maps.Clone(m.users.versions)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	m.users.versions = versions
+	return fn(ctx)
+}
+
+// versionedUserRepository checks versions on Update like the Postgres repository.
+type versionedUserRepository struct {
+	ports.UserRepository
+	versions map[int64]int
+}
+
+func (r *versionedUserRepository) Update(ctx context.Context, user *domain.User) error {
+	if r.versions[user.ID] != user.Version {
+		return domain.ErrUpdateConflict
+	}
+	r.versions[user.ID]++
+	user.Version++
+	return nil
+}
+
+type fakeTokenService struct {
+	ports.TokenService
+}
+
+func (fakeTokenService) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
+	return nil
+}
+
+func TestUserServiceRetriesFromOriginalUser(t *testing.T) {
+	users := &versionedUserRepository{versions: map[int64]int{1: 3}}
+	svc := services.NewUserService(users, fakeTokenService{}, fakeAudit{}, retryingTxManager{users: users})
+	user := &domain.User{ID: 1, Name: "Alice", Version: 3}
+
+	if err := svc.UpdateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Version != 4 || users.versions[1] != 4 {
+		t.Errorf("version = %d, stored %d; want 4", user.Version, users.versions[1])
+	}
+
+	if err := svc.SetUserActivated(context.Background(), user, true); err != nil {
+		t.Fatal(err)
+	}
+	if !user.Activated || user.Version != 5 {
+		t.Errorf("activated %v with version %d, want activated with version 5", user.Activated, user.Version)
+	}
+}
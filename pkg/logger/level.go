@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/thaian1234/green_light/config"
+	"go.uber.org/zap/zapcore"
+)
+
+// Named sub-loggers whose level can be set independently of the root logger.
+const (
+	HTTP   = "http"
+	DB     = "db"
+	Mailer = "mailer"
+)
+
+// Root is the name used to address the root logger's level.
+const Root = "root"
+
+var subLoggers = []string{HTTP, DB, Mailer}
+
+// levels holds the root level and per sub-logger overrides. Sub-loggers without an
+// override follow the root level.
+type levels struct {
+	mu        sync.RWMutex
+	root      zapcore.Level
+	overrides map[string]zapcore.Level
+}
+
+func newLevels(root zapcore.Level) *levels {
+	return &levels{
+		root:      root,
+		overrides: make(map[string]zapcore.Level),
+	}
+}
+
+func (l *levels) enabled(name string, level zapcore.Level) bool {
+	name, _, _ = strings.Cut(name, ".")
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if override, ok := l.overrides[name]; ok {
+		return level >= override
+	}
+	return level >= l.root
+}
+
+// anyEnabled reports whether level is enabled for at least one logger.
+func (l *levels) anyEnabled(level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if level >= l.root {
+		return true
+	}
+	for _, override := range l.overrides {
+		if level >= override {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *levels) get(name string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if override, ok := l.overrides[name]; ok {
+		return override
+	}
+	return l.root
+}
+
+func (l *levels) set(name string, level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if name == Root {
+		l.root = level
+		return
+	}
+	l.overrides[name] = level
+}
+
+func (l *levels) reset(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.overrides, name)
+}
+
+// levelCore filters entries by the level of the logger that wrote them.
+type levelCore struct {
+	zapcore.Core
+	levels *levels
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.anyEnabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.enabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// Levels returns the current level of the root logger and every sub-logger.
+func Levels() map[string]string {
+	levels := map[string]string{
+		Root: instance.levels.get(Root).String(),
+	}
+	for _, name := range subLoggers {
+		levels[name] = instance.levels.get(name).String()
+	}
+	return levels
+}
+
+// SetLevel changes the level of the root logger or of a sub-logger at runtime. The
+// change itself is always logged, whatever the new level.
+func SetLevel(name, level string) error {
+	if name == "" {
+		name = Root
+	}
+	if name != Root && !isSubLogger(name) {
+		return fmt.Errorf("unknown logger %q, expected one of %s", name, strings.Join(append([]string{Root}, subLoggers...), ", "))
+	}
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	previous := instance.levels.get(name)
+	instance.levels.set(name, parsed)
+	if previous != parsed {
+		instance.unfiltered.Infow("log level changed", "logger", name, "from", previous.String(), "to", parsed.String())
+	}
+	return nil
+}
+
+// ReloadLevels applies the levels from cfg, dropping runtime overrides of sub-loggers
+// that cfg does not mention.
+func ReloadLevels(cfg *config.Logger) error {
+	overrides, err := parseLevels(cfg.LogLevels)
+	if err != nil {
+		return err
+	}
+	if err := SetLevel(Root, cfg.LogLevel); err != nil {
+		return err
+	}
+	for _, name := range subLoggers {
+		if level, ok := overrides[name]; ok {
+			if err := SetLevel(name, level); err != nil {
+				return err
+			}
+			continue
+		}
+		previous := instance.levels.get(name)
+		instance.levels.reset(name)
+		if current := instance.levels.get(name); current != previous {
+			instance.unfiltered.Infow("log level changed", "logger", name, "from", previous.String(), "to", current.String())
+		}
+	}
+	return nil
+}
+
+// parseLevels parses "name=level" pairs such as "http=debug,db=warn".
+func parseLevels(value string) (map[string]string, error) {
+	levels := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, level, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid logger level %q, expected name=level", pair)
+		}
+		levels[strings.TrimSpace(name)] = strings.TrimSpace(level)
+	}
+	return levels, nil
+}
+
+func isSubLogger(name string) bool {
+	for _, subLogger := range subLoggers {
+		if subLogger == name {
+			return true
+		}
+	}
+	return false
+}
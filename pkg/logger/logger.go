@@ -1,7 +1,11 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/thaian1234/green_light/config"
 	"go.uber.org/zap"
@@ -14,27 +18,79 @@ var (
 	once     sync.Once
 )
 
+const (
+	outputStdout  = "stdout"
+	outputFile    = "file"
+	formatConsole = "console"
+)
+
+type contextKey struct{}
+
 type Adapter struct {
 	logger *zap.SugaredLogger
+	// levels and unfiltered are only set on the root adapter.
+	levels     *levels
+	unfiltered *zap.SugaredLogger
 }
 
+// NewAdapter builds a logger writing to every configured output. Stdout is human
+// readable outside production and JSON in production. Levels are checked per logger
+// name, so they can be changed at runtime with SetLevel.
 func NewAdapter(cfg *config.Logger) (*Adapter, error) {
-	writerSyncer := getLogWritter(cfg)
-	encoder := getEncoder()
-
-	level, err := zapcore.ParseLevel(cfg.LogLevel)
+	rootLevel, err := zapcore.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	levels := newLevels(rootLevel)
+	overrides, err := parseLevels(cfg.LogLevels)
 	if err != nil {
 		return nil, err
 	}
+	for name, level := range overrides {
+		if !isSubLogger(name) {
+			return nil, fmt.Errorf("unknown logger %q in log levels", name)
+		}
+		parsed, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		levels.set(name, parsed)
+	}
+	// Cores accept every level; levelCore does the filtering.
+	level := zapcore.DebugLevel
+
+	var cores []zapcore.Core
+	for _, output := range cfg.Outputs {
+		switch output {
+		case outputStdout:
+			cores = append(cores, zapcore.NewCore(getStdoutEncoder(cfg), zapcore.Lock(os.Stdout), level))
+		case outputFile:
+			cores = append(cores, zapcore.NewCore(getEncoder(), getLogWritter(cfg), level))
+		default:
+			return nil, fmt.Errorf("unknown log output %q", output)
+		}
+	}
 
-	core := zapcore.NewCore(encoder, writerSyncer, level)
-	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
+	core := wrapCore(zapcore.NewTee(cores...), cfg)
+	logger := zap.New(&levelCore{Core: core, levels: levels}, zap.AddCaller(), zap.AddCallerSkip(1))
 
 	return &Adapter{
-		logger: logger.Sugar(),
+		logger:     logger.Sugar(),
+		levels:     levels,
+		unfiltered: zap.New(core, zap.AddCaller()).Sugar(),
 	}, nil
 }
 
+// wrapCore redacts what core writes and samples in front of that, so an entry dropped
+// by the sampler is never written and a kept one is always redacted.
+func wrapCore(core zapcore.Core, cfg *config.Logger) zapcore.Core {
+	core = newRedactCore(core, cfg.RedactFields)
+	if cfg.SamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+	return core
+}
+
 func Initialize(cfg *config.Logger) error {
 	var err error
 	once.Do(func() {
@@ -55,6 +111,21 @@ func getEncoder() zapcore.Encoder {
 	return zapcore.NewJSONEncoder(encoderConfig)
 }
 
+func getStdoutEncoder(cfg *config.Logger) zapcore.Encoder {
+	format := cfg.StdoutFormat
+	if format == "" && cfg.Env != "production" {
+		format = formatConsole
+	}
+	if format != formatConsole {
+		return getEncoder()
+	}
+
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
 func getLogWritter(cfg *config.Logger) zapcore.WriteSyncer {
 	lumberJackLogger := &lumberjack.Logger{
 		Filename:   cfg.LogPath,
@@ -86,3 +157,47 @@ func Error(msg string, fields ...interface{}) {
 func Fatal(msg string, fields ...interface{}) {
 	GetLogger().logger.Fatalw(msg, fields...)
 }
+
+// With returns a copy of ctx whose logger adds fields to every entry, on top of any
+// fields already attached to ctx.
+func With(ctx context.Context, fields ...interface{}) context.Context {
+	return NewContext(ctx, &Adapter{
+		logger: FromContext(ctx).logger.With(fields...),
+	})
+}
+
+// NewContext returns a copy of ctx that carries l.
+func NewContext(ctx context.Context, l *Adapter) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// Named returns a sub-logger whose level follows the override set for name, if any.
+func (a *Adapter) Named(name string) *Adapter {
+	return &Adapter{
+		logger: a.logger.Named(name),
+	}
+}
+
+// FromContext returns the logger attached to ctx by With, or the global logger.
+func FromContext(ctx context.Context) *Adapter {
+	if a, ok := ctx.Value(contextKey{}).(*Adapter); ok {
+		return a
+	}
+	return GetLogger()
+}
+
+func (a *Adapter) Debug(msg string, fields ...interface{}) {
+	a.logger.Debugw(msg, fields...)
+}
+
+func (a *Adapter) Info(msg string, fields ...interface{}) {
+	a.logger.Infow(msg, fields...)
+}
+
+func (a *Adapter) Warn(msg string, fields ...interface{}) {
+	a.logger.Warnw(msg, fields...)
+}
+
+func (a *Adapter) Error(msg string, fields ...interface{}) {
+	a.logger.Errorw(msg, fields...)
+}
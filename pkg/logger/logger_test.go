@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/thaian1234/green_light/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWrapCoreSamplesAndRedacts(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(wrapCore(observed, &config.Logger{
+		SamplingInitial:    2,
+		SamplingThereafter: 3,
+		RedactFields:       []string{"api_key"},
+	})).With(zap.String("api_key", "gl_secret"))
+
+	for range 8 {
+		logger.Info("login failed", zap.String("email", "alice@example.com"), zap.String("password", "hunter2"))
+	}
+
+	// The first two entries are kept, then every third: the 5th and the 8th.
+	if got := logs.Len(); got != 4 {
+		t.Fatalf("wrote %d entries, want 4", got)
+	}
+	for _, entry := range logs.All() {
+		fields := entry.ContextMap()
+		if fields["email"] != "a***@example.com" || fields["password"] != redacted || fields["api_key"] != redacted {
+			t.Errorf("fields = %v, want them redacted", fields)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// defaultRedactedFields are always masked, whatever the configuration adds.
+var defaultRedactedFields = []string{
+	"password",
+	"password_hash",
+	"current_password",
+	"token",
+	"secret",
+	"client_secret",
+	"authorization",
+	"cookie",
+	"email",
+}
+
+// redactCore masks sensitive fields before they reach the encoders. Fields are matched
+// by key, case-insensitively; emails keep their domain so logs stay useful. Check adds
+// the redact core itself rather than the wrapped cores, so that writes go through
+// Write; cores that decide in Check, such as a sampler, must wrap it instead.
+type redactCore struct {
+	zapcore.Core
+	fields map[string]struct{}
+}
+
+func newRedactCore(core zapcore.Core, extra []string) zapcore.Core {
+	fields := make(map[string]struct{})
+	for _, key := range append(defaultRedactedFields, extra...) {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			fields[key] = struct{}{}
+		}
+	}
+	return &redactCore{Core: core, fields: fields}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redact(fields)), fields: c.fields}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redact(fields))
+}
+
+func (c *redactCore) redact(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fields {
+		key := strings.ToLower(field.Key)
+		if _, ok := c.fields[key]; !ok {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		if key == "email" && field.Type == zapcore.StringType {
+			out[i] = zap.String(field.Key, maskEmail(field.String))
+			continue
+		}
+		out[i] = zap.String(field.Key, redacted)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// maskEmail keeps the first character of the local part and the domain.
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return redacted
+	}
+	return local[:1] + "***@" + domain
+}
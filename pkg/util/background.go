@@ -1,22 +1,34 @@
 package util
 
 import (
+	"context"
 	"sync"
 
 	"github.com/thaian1234/green_light/pkg/logger"
+	"github.com/thaian1234/green_light/pkg/metrics"
+	"github.com/thaian1234/green_light/pkg/tracing"
 )
 
-func Background(wg *sync.WaitGroup, fn func()) {
+// Background runs fn in a goroutine tracked by wg, recovering any panic. fn receives a
+// context that keeps the trace and logger of ctx but none of its cancellation or other
+// values, since request contexts are cancelled, and gin contexts recycled, once the
+// response is sent.
+func Background(ctx context.Context, wg *sync.WaitGroup, fn func(ctx context.Context)) {
+	log := logger.FromContext(ctx)
+	ctx, span := tracing.Start(logger.NewContext(tracing.Detach(ctx), log), "util.Background")
 	wg.Add(1)
+	metrics.BackgroundGoroutines.Inc()
 	go func() {
 		defer wg.Done()
+		defer metrics.BackgroundGoroutines.Dec()
+		defer span.End()
 
 		defer func() {
 			if err := recover(); err != nil {
-				logger.Error("handle panic in goroutine", "msg", err)
+				log.Error("handle panic in goroutine", "msg", err)
 			}
 		}()
 
-		fn()
+		fn(ctx)
 	}()
 }